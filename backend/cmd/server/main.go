@@ -8,6 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-ssl-monitor/internal/api"
 	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/job"
+	"github.com/go-ssl-monitor/internal/notify"
+	"github.com/go-ssl-monitor/internal/oncall"
 )
 
 func main() {
@@ -15,14 +18,32 @@ func main() {
 	configPath := filepath.Join("configs", "config.yaml")
 	config.LoadConfig(configPath)
 
+	// 初始化通知渠道
+	if err := notify.Init(config.AppConfig.Notifications, &config.AppConfig.Email); err != nil {
+		log.Fatalf("Failed to initialize notification channels: %v", err)
+	}
+
 	// 初始化数据库连接
 	config.InitDB()
-	
+	notify.SetOnCallLookup(oncall.Lookup(config.DB))
+
+	// 启动后台任务
+	job.StartBackupWatchdog(config.DB, config.AppConfig.Backup.WatchdogIntervalDuration(), config.AppConfig.Backup.RestoreMaxAge)
+	job.StartOutboxWorker(config.DB, config.AppConfig.Outbox.IntervalDuration())
+	job.StartBackupRetention(config.DB, config.AppConfig.Backup.Retention)
+	if err := job.StartDigests(config.DB, config.AppConfig.Digests); err != nil {
+		log.Fatalf("Failed to schedule digests: %v", err)
+	}
+
 	// 创建gin实例
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
+	// 客户端地址用于摄取令牌的来源地址绑定，只采信可信代理转发的 X-Forwarded-For
+	if err := r.SetTrustedProxies(config.AppConfig.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid server.trusted_proxies: %v", err)
+	}
 
 	// 添加数据库中间件
 	r.Use(func(c *gin.Context) {
@@ -51,6 +72,18 @@ func main() {
 			auth.POST("/login", api.Login)
 		}
 
+		// 备份脚本写入路由，接受用户token或摄取令牌
+		ingest := apiGroup.Group("")
+		ingest.Use(api.IngestAuthMiddleware())
+		{
+			ingest.POST("/backupLogs", api.CreateBackupLog)
+			ingest.PUT("/backupLogs/:id", api.UpdateBackupLog)
+			ingest.POST("/backupLogs/:id/output", api.UploadBackupOutput)
+			ingest.POST("/restoreTests", api.CreateRestoreTest)
+			ingest.GET("/getId/:ip", api.GetLastBackupLogByIP)
+			ingest.GET("/getStatus/:ip", api.GetBackupStatusByIP)
+		}
+
 		// 需要认证的路由
 		protected := apiGroup.Group("")
 		protected.Use(api.AuthMiddleware())
@@ -62,13 +95,94 @@ func main() {
 			protected.DELETE("/domains/:id", api.DeleteDomain)
 			protected.POST("/domains/:id/check", api.CheckDomainCertificate)
 			protected.PUT("/domains/:id/auto-renewal", api.ToggleAutoRenewal)
+			protected.GET("/domains/:id/findings", api.GetDomainFindings)
+			protected.GET("/domains/:id/http-check", api.GetDomainHTTPCheck)
+			protected.GET("/domains/:id/pins", api.GetDomainPins)
+			protected.POST("/domains/:id/pins", api.AddDomainPin)
+			protected.DELETE("/domains/:id/pins/:pinId", api.DeleteDomainPin)
+			protected.GET("/domains/:id/pins/preview", api.PreviewDomainPins)
+			protected.GET("/certificates/coverage", api.GetCertificateCoverage)
 
 			// 备份日志相关路由
 			protected.GET("/backupLogs", api.GetBackupLogs)
-			protected.POST("/backupLogs", api.CreateBackupLog)
-			protected.PUT("/backupLogs/:id", api.UpdateBackupLog)
-			protected.GET("/getId/:ip", api.GetLastBackupLogByIP)
-			protected.GET("/getStatus/:ip", api.GetBackupStatusByIP)
+			protected.GET("/backupLogs/analytics", api.GetBackupAnalytics)
+			protected.GET("/backupAlertStates", api.GetBackupAlertStates)
+			protected.GET("/backupRollups", api.GetBackupRollups)
+			protected.GET("/scriptVersions", api.GetScriptVersions)
+			protected.GET("/backupLogs/:id/output", api.GetBackupOutput)
+			protected.DELETE("/backupLogs/:id", api.DeleteBackupLog)
+			protected.GET("/restoreTests", api.GetRestoreTests)
+
+			// 备份脚本摄取令牌管理
+			protected.GET("/agentTokens", api.GetAgentTokens)
+			protected.POST("/agentTokens", api.CreateAgentToken)
+			protected.POST("/agentTokens/:id/rotate", api.RotateAgentToken)
+			protected.DELETE("/agentTokens/:id", api.RevokeAgentToken)
+
+			// 通知发件箱
+			protected.GET("/outbox", api.GetOutboxMessages)
+			protected.POST("/outbox/:id/resend", api.ResendOutboxMessage)
+			protected.GET("/notifications", api.GetNotifications)
+			protected.GET("/notificationChannels", api.GetNotificationChannels)
+			protected.GET("/emailTemplates", api.GetEmailTemplates)
+			protected.POST("/emailTemplates/preview", api.PreviewEmailTemplate)
+			protected.PUT("/emailTemplates/:name/:lang", api.SaveEmailTemplate)
+			protected.DELETE("/emailTemplates/:name/:lang", api.DeleteEmailTemplate)
+			protected.POST("/notificationChannels/:name/test", api.TestNotificationChannel)
+			protected.GET("/notificationRoutes", api.GetNotificationRoutes)
+			protected.POST("/notificationRoutes/dryRun", api.DryRunNotificationRoute)
+			protected.GET("/digests", api.GetDigests)
+			protected.GET("/digests/:name/preview", api.PreviewDigest)
+			protected.POST("/digests/:name/send", api.SendDigest)
+
+			// 告警处理与维护窗口
+			protected.GET("/alerts", api.GetAlerts)
+			protected.GET("/alerts/:id", api.GetAlert)
+			protected.POST("/alerts/:id/ack", api.AcknowledgeAlert)
+			protected.POST("/alerts/:id/snooze", api.SnoozeAlert)
+			protected.POST("/alerts/:id/resolve", api.ResolveAlert)
+			protected.GET("/maintenanceWindows", api.GetMaintenanceWindows)
+			protected.POST("/maintenanceWindows", api.AddMaintenanceWindow)
+			protected.PUT("/maintenanceWindows/:id", api.UpdateMaintenanceWindow)
+			protected.DELETE("/maintenanceWindows/:id", api.DeleteMaintenanceWindow)
+			protected.GET("/onCallRotations", api.GetOnCallRotations)
+			protected.POST("/onCallRotations", api.AddOnCallRotation)
+			protected.GET("/onCallRotations/:id", api.GetOnCallRotation)
+			protected.PUT("/onCallRotations/:id", api.UpdateOnCallRotation)
+			protected.DELETE("/onCallRotations/:id", api.DeleteOnCallRotation)
+			protected.POST("/onCallRotations/:id/overrides", api.AddOnCallOverride)
+			protected.DELETE("/onCallRotations/:id/overrides/:overrideId", api.DeleteOnCallOverride)
+
+			// 备份服务器资产
+			protected.GET("/servers", api.GetServers)
+			protected.POST("/servers", api.AddServer)
+			protected.PUT("/servers/:id", api.UpdateServer)
+			protected.DELETE("/servers/:id", api.DeleteServer)
+			protected.GET("/servers/:id/summary", api.GetServerSummary)
+
+			// 备份来源登记
+			protected.GET("/backupSources", api.GetBackupSources)
+			protected.POST("/backupSources", api.AddBackupSource)
+			protected.PUT("/backupSources/:id", api.UpdateBackupSource)
+			protected.DELETE("/backupSources/:id", api.DeleteBackupSource)
+		}
+	}
+
+	// 原 lincoln/backup_api 的路由，供尚未迁移的备份脚本使用：来自 legacy_allowed_ips 的请求无需认证，其他请求需要摄取令牌或用户 token
+	if config.AppConfig.Backup.LegacyRoutes {
+		legacyAuth, err := api.LegacyAuthMiddleware(config.AppConfig.Backup.LegacyAllowedIPs)
+		if err != nil {
+			log.Fatalf("Invalid backup.legacy_allowed_ips: %v", err)
+		}
+		legacy := r.Group("")
+		legacy.Use(legacyAuth)
+		{
+			legacy.GET("/backupLogs", api.GetAllBackupLogs)
+			legacy.POST("/backupLogs", api.CreateBackupLog)
+			legacy.PUT("/backupLogs/:id", api.UpdateBackupLog)
+			legacy.DELETE("/backupLogs/:id", api.DeleteBackupLog)
+			legacy.GET("/getId/:ip", api.GetLastBackupLogByIP)
+			legacy.GET("/getStatus/:ip", api.GetBackupStatusByIP)
 		}
 	}
 
@@ -0,0 +1,267 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/notify"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// Target 告警对象：域名，或按地址确定的服务器
+type Target struct {
+	DomainID uint
+	ServerID uint
+	Ip       string
+}
+
+// Condition 检查发现的一个告警条件
+type Condition struct {
+	Key      string // 告警条件标识，同一条件重复出现时更新同一条告警
+	Kind     string
+	Severity string
+	Message  string
+	Target
+}
+
+// 各类告警条件的标识
+func BackupFailureKey(ip string) string   { return "backup:" + ip }
+func BackupMissingKey(ip string) string   { return "backup_missing:" + ip }
+func RestoreMissingKey(ip string) string  { return "restore_missing:" + ip }
+func ScriptDowngradeKey(ip string) string { return "script_downgrade:" + ip }
+func PinMismatchKey(domainID uint) string { return fmt.Sprintf("pin:%d", domainID) }
+
+// DedupKey 告警在 PagerDuty、Opsgenie 中的去重键，同一条告警的重复通知合并为一个事件
+func DedupKey(a *model.Alert) string {
+	return fmt.Sprintf("%s#%d", a.Key, a.ID)
+}
+
+// Message 返回告警通知的发件箱消息：通知类型、级别、路由属性、去重键和关联的告警对象，由调用方填写收件人和内容
+func Message(db *gorm.DB, a *model.Alert) outbox.Message {
+	return outbox.Message{
+		Kind:     a.Kind,
+		Severity: a.Severity,
+		Labels:   LabelsFor(db, Target{DomainID: a.DomainID, ServerID: a.ServerID, Ip: a.Ip}),
+		DedupKey: DedupKey(a),
+		AlertID:  a.ID,
+		DomainID: a.DomainID,
+		ServerID: a.ServerID,
+		Ip:       a.Ip,
+	}
+}
+
+// ServerTarget 根据规范化后的地址查找登记的服务器，未登记时 ServerID 为 0
+func ServerTarget(db *gorm.DB, ip string) Target {
+	t := Target{Ip: ip}
+	var addr model.ServerAddress
+	if err := db.Where("ip = ?", ip).First(&addr).Error; err == nil {
+		t.ServerID = addr.ServerID
+	}
+	return t
+}
+
+// LabelsFor 返回告警对象的路由属性：域名的标签、环境和负责团队，或登记的服务器的标签、环境和负责人
+func LabelsFor(db *gorm.DB, t Target) notify.Labels {
+	if t.DomainID != 0 {
+		var domain model.Domain
+		if err := db.First(&domain, t.DomainID).Error; err != nil {
+			return notify.Labels{}
+		}
+		return notify.Labels{Tags: splitList(domain.Tags), Environment: domain.Environment, OwnerTeam: domain.OwnerTeam}
+	}
+	serverID := t.ServerID
+	if serverID == 0 && t.Ip != "" {
+		serverID = ServerTarget(db, t.Ip).ServerID
+	}
+	var server model.Server
+	if serverID == 0 || db.First(&server, serverID).Error != nil {
+		return notify.Labels{}
+	}
+	return notify.Labels{Tags: splitList(server.Tags), Environment: server.Environment, OwnerTeam: server.Owner}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Raise 记录告警条件并返回是否应发送通知。
+// 新条件创建 open 状态的告警；已确认或暂停中的告警只更新出现次数，暂停到期后重新打开；
+// 对象处于维护窗口时记录告警但不通知
+func Raise(db *gorm.DB, c Condition, now time.Time) (*model.Alert, bool) {
+	a, err := Active(db, c.Key)
+	if err != nil {
+		a = &model.Alert{
+			Key:      c.Key,
+			Kind:     c.Kind,
+			DomainID: c.DomainID,
+			ServerID: c.ServerID,
+			Ip:       c.Ip,
+			Status:   model.AlertOpen,
+		}
+	}
+	isNew := a.ID == 0
+	a.Kind = c.Kind
+	a.Severity = c.Severity
+	a.Message = c.Message
+	a.Occurrences++
+	a.LastSeenAt = now
+
+	notify := true
+	event := ""
+	switch a.Status {
+	case model.AlertAcknowledged:
+		notify = false
+	case model.AlertSnoozed:
+		if now.Before(a.SnoozedUntil) {
+			notify = false
+		} else {
+			a.Status = model.AlertOpen
+			a.SnoozedUntil = time.Time{}
+			event = model.AlertEventReopened
+		}
+	}
+	if isNew {
+		event = model.AlertEventOpened
+	}
+
+	comment := ""
+	if notify {
+		if w, ok := InMaintenance(db, c.Target, now); ok {
+			notify = false
+			comment = fmt.Sprintf("维护窗口 #%d（%s 至 %s）%s", w.ID,
+				w.StartsAt.Format("2006-01-02 15:04"), w.EndsAt.Format("2006-01-02 15:04"), w.Reason)
+		}
+	}
+	if notify {
+		a.LastNotifiedAt = now
+	}
+
+	if err := db.Save(a).Error; err != nil {
+		log.Printf("Alert: failed to save alert %s: %v", c.Key, err)
+		return a, notify
+	}
+	if event != "" {
+		addEvent(db, a, event, "", "")
+	}
+	if comment != "" {
+		addEvent(db, a, model.AlertEventSuppressed, "", comment)
+	}
+	return a, notify
+}
+
+// Resolve 条件消失时自动解决 Key 对应的未解决告警，没有时返回 nil
+func Resolve(db *gorm.DB, key string, now time.Time) *model.Alert {
+	a, err := Active(db, key)
+	if err != nil {
+		return nil
+	}
+	if err := SetResolved(db, a, "", "", now); err != nil {
+		log.Printf("Alert: failed to resolve alert %s: %v", key, err)
+	}
+	return a
+}
+
+// Active 返回 Key 对应的未解决告警
+func Active(db *gorm.DB, key string) (*model.Alert, error) {
+	var a model.Alert
+	err := db.Where("`key` = ? AND status <> ?", key, model.AlertResolved).Order("id DESC").First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Acknowledge 确认告警，之后不再通知，直到告警解决
+func Acknowledge(db *gorm.DB, a *model.Alert, user, comment string, now time.Time) error {
+	if a.Status == model.AlertResolved {
+		return errors.New("告警已解决")
+	}
+	a.Status = model.AlertAcknowledged
+	a.SnoozedUntil = time.Time{}
+	a.AcknowledgedBy = user
+	a.AcknowledgedAt = now
+	if err := db.Save(a).Error; err != nil {
+		return err
+	}
+	addEvent(db, a, model.AlertEventAcknowledged, user, comment)
+	followUpIncident(db, a, notify.ActionAcknowledge)
+	return nil
+}
+
+// Snooze 暂停告警通知到指定时间，到期后告警条件仍存在时重新通知
+func Snooze(db *gorm.DB, a *model.Alert, until time.Time, user, comment string, now time.Time) error {
+	if a.Status == model.AlertResolved {
+		return errors.New("告警已解决")
+	}
+	if !until.After(now) {
+		return errors.New("暂停时间必须晚于当前时间")
+	}
+	a.Status = model.AlertSnoozed
+	a.SnoozedUntil = until
+	if err := db.Save(a).Error; err != nil {
+		return err
+	}
+	addEvent(db, a, model.AlertEventSnoozed, user, comment+fmt.Sprintf("（至 %s）", until.Format("2006-01-02 15:04:05")))
+	return nil
+}
+
+// SetResolved 将告警标记为已解决，user 为空表示条件消失后自动解决
+func SetResolved(db *gorm.DB, a *model.Alert, user, comment string, now time.Time) error {
+	if a.Status == model.AlertResolved {
+		return errors.New("告警已解决")
+	}
+	a.Status = model.AlertResolved
+	a.ResolvedAt = now
+	if err := db.Save(a).Error; err != nil {
+		return err
+	}
+	addEvent(db, a, model.AlertEventResolved, user, comment)
+	followUpIncident(db, a, notify.ActionResolve)
+	return nil
+}
+
+// InMaintenance 返回对象当前所处的维护窗口
+func InMaintenance(db *gorm.DB, t Target, now time.Time) (*model.MaintenanceWindow, bool) {
+	if t.DomainID == 0 && t.ServerID == 0 {
+		return nil, false
+	}
+	query := db.Where("starts_at <= ? AND ends_at > ?", now, now)
+	switch {
+	case t.DomainID != 0 && t.ServerID != 0:
+		query = query.Where("(domain_id = ? OR server_id = ?)", t.DomainID, t.ServerID)
+	case t.DomainID != 0:
+		query = query.Where("domain_id = ?", t.DomainID)
+	default:
+		query = query.Where("server_id = ?", t.ServerID)
+	}
+	var w model.MaintenanceWindow
+	if err := query.Order("ends_at DESC").First(&w).Error; err != nil {
+		return nil, false
+	}
+	return &w, true
+}
+
+// followUpIncident 确认或关闭告警在 PagerDuty、Opsgenie 中的事件，发送失败的由发件箱重试
+func followUpIncident(db *gorm.DB, a *model.Alert, action string) {
+	if err := outbox.FollowUpIncident(db, DedupKey(a), action); err != nil {
+		log.Printf("Alert: failed to %s incident for alert %d, queued for retry: %v", action, a.ID, err)
+	}
+}
+
+func addEvent(db *gorm.DB, a *model.Alert, action, user, comment string) {
+	event := model.AlertEvent{AlertID: a.ID, Action: action, User: user, Comment: comment}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("Alert: failed to record %s event for alert %d: %v", action, a.ID, err)
+	}
+}
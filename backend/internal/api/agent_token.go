@@ -0,0 +1,206 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// agentTokenPrefix 摄取令牌的固定前缀，用于和用户 JWT 区分
+const agentTokenPrefix = "bsa_"
+
+type AgentTokenRequest struct {
+	Name       string `json:"name"`
+	Ip         string `json:"ip" binding:"required"`
+	ServerName string `json:"serverName"`
+	SourceIP   string `json:"sourceIp"`
+}
+
+// generateAgentToken 生成新的摄取令牌，返回明文和哈希
+func generateAgentToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := agentTokenPrefix + hex.EncodeToString(buf)
+	return token, hashAgentToken(token), nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IngestAuthMiddleware 备份日志写入接口的认证，接受用户 JWT 或备份脚本的摄取令牌
+func IngestAuthMiddleware() gin.HandlerFunc {
+	userAuth := AuthMiddleware()
+	return func(c *gin.Context) {
+		tokenString := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if !strings.HasPrefix(tokenString, agentTokenPrefix) {
+			userAuth(c)
+			return
+		}
+
+		db := c.MustGet("db").(*gorm.DB)
+		var token model.AgentToken
+		if err := db.Where("token_hash = ? AND revoked = ?", hashAgentToken(tokenString), false).First(&token).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
+			c.Abort()
+			return
+		}
+		if clientIP, _ := model.NormalizeIP(c.ClientIP()); token.SourceIP != "" && token.SourceIP != clientIP {
+			c.JSON(http.StatusForbidden, gin.H{"error": "请求来源地址与token绑定地址不符"})
+			c.Abort()
+			return
+		}
+
+		db.Model(&token).UpdateColumn("last_used_at", time.Now())
+		c.Set("agent_token", &token)
+		c.Next()
+	}
+}
+
+// LegacyAuthMiddleware 原 backup_api 路由的认证：旧版备份脚本不发送认证头，来自 allowed 中地址或网段的请求无需令牌；
+// 其他请求与 IngestAuthMiddleware 相同，需要摄取令牌或用户 token
+func LegacyAuthMiddleware(allowed []string) (gin.HandlerFunc, error) {
+	prefixes := make([]netip.Prefix, 0, len(allowed))
+	for _, a := range allowed {
+		prefix, err := netip.ParsePrefix(a)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(a)
+			if addrErr != nil {
+				return nil, fmt.Errorf("无效的地址或网段: %s", a)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	ingestAuth := IngestAuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if addr, err := netip.ParseAddr(c.ClientIP()); err == nil {
+				addr = addr.Unmap()
+				for _, prefix := range prefixes {
+					if prefix.Contains(addr) {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+		ingestAuth(c)
+	}, nil
+}
+
+// agentTokenFromContext 返回当前请求使用的摄取令牌，用户请求返回 nil
+func agentTokenFromContext(c *gin.Context) *model.AgentToken {
+	if v, ok := c.Get("agent_token"); ok {
+		return v.(*model.AgentToken)
+	}
+	return nil
+}
+
+// GetAgentTokens 获取所有摄取令牌
+func GetAgentTokens(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var tokens []model.AgentToken
+	if err := db.Order("id ASC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取令牌列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateAgentToken 为服务器签发摄取令牌，明文令牌只在响应中返回一次
+func CreateAgentToken(c *gin.Context) {
+	var req AgentTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	ip, err := model.NormalizeIP(req.Ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Ip = ip
+	if req.SourceIP != "" {
+		if req.SourceIP, err = model.NormalizeIP(req.SourceIP); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	plain, hash, err := generateAgentToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		return
+	}
+
+	token := model.AgentToken{
+		Name:        req.Name,
+		Ip:          req.Ip,
+		ServerName:  req.ServerName,
+		SourceIP:    req.SourceIP,
+		TokenHash:   hash,
+		TokenPrefix: plain[:len(agentTokenPrefix)+6],
+	}
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": plain, "agentToken": token})
+}
+
+// RotateAgentToken 轮换摄取令牌，旧令牌立即失效
+func RotateAgentToken(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var token model.AgentToken
+	if err := db.Where("id = ? AND revoked = ?", c.Param("id"), false).First(&token).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "令牌不存在或已吊销"})
+		return
+	}
+
+	plain, hash, err := generateAgentToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		return
+	}
+	token.TokenHash = hash
+	token.TokenPrefix = plain[:len(agentTokenPrefix)+6]
+	if err := db.Save(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "轮换令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": plain, "agentToken": token})
+}
+
+// RevokeAgentToken 吊销摄取令牌
+func RevokeAgentToken(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	result := db.Model(&model.AgentToken{}).Where("id = ? AND revoked = ?", c.Param("id"), false).
+		Updates(map[string]interface{}{"revoked": true, "revoked_at": time.Now()})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "吊销令牌失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "令牌不存在或已吊销"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "令牌已吊销"})
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// AlertActionRequest 确认、暂停或解决告警的请求
+type AlertActionRequest struct {
+	Comment string    `json:"comment"`
+	Until   time.Time `json:"until"`   // 暂停到的时间，与 minutes 二选一
+	Minutes int       `json:"minutes"` // 暂停的分钟数
+}
+
+// MaintenanceWindowRequest 维护窗口的请求数据，domainId 与 serverId 二选一
+type MaintenanceWindowRequest struct {
+	DomainID uint      `json:"domainId"`
+	ServerID uint      `json:"serverId"`
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// GetAlerts 查询告警，支持 status（可为 active，表示所有未解决的告警）、kind、domain_id、server_id、ip 参数
+func GetAlerts(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.Alert{})
+
+	switch status := c.Query("status"); status {
+	case "":
+	case "active":
+		query = query.Where("status <> ?", model.AlertResolved)
+	default:
+		query = query.Where("status = ?", status)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		normalized, err := model.NormalizeIP(ip)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+			return
+		}
+		query = query.Where("ip = ?", normalized)
+	}
+	for _, field := range []string{"kind", "domain_id", "server_id"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+
+	alerts := []model.Alert{}
+	if err := query.Order("id DESC").Limit(500).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取告警失败"})
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// GetAlert 获取告警及其处理记录
+func GetAlert(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var a model.Alert
+	err := db.Preload("Events", func(tx *gorm.DB) *gorm.DB { return tx.Order("id ASC") }).First(&a, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "告警不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// AcknowledgeAlert 确认告警，告警解决前不再发送通知
+func AcknowledgeAlert(c *gin.Context) {
+	updateAlert(c, func(db *gorm.DB, a *model.Alert, req *AlertActionRequest, user string, now time.Time) error {
+		return alert.Acknowledge(db, a, user, req.Comment, now)
+	})
+}
+
+// SnoozeAlert 暂停告警通知到 until 或 minutes 分钟后
+func SnoozeAlert(c *gin.Context) {
+	updateAlert(c, func(db *gorm.DB, a *model.Alert, req *AlertActionRequest, user string, now time.Time) error {
+		until := req.Until
+		if until.IsZero() && req.Minutes > 0 {
+			until = now.Add(time.Duration(req.Minutes) * time.Minute)
+		}
+		return alert.Snooze(db, a, until, user, req.Comment, now)
+	})
+}
+
+// ResolveAlert 手动解决告警，告警条件再次出现时会创建新的告警
+func ResolveAlert(c *gin.Context) {
+	updateAlert(c, func(db *gorm.DB, a *model.Alert, req *AlertActionRequest, user string, now time.Time) error {
+		return alert.SetResolved(db, a, user, req.Comment, now)
+	})
+}
+
+func updateAlert(c *gin.Context, apply func(db *gorm.DB, a *model.Alert, req *AlertActionRequest, user string, now time.Time) error) {
+	db := c.MustGet("db").(*gorm.DB)
+	var a model.Alert
+	if err := db.First(&a, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "告警不存在"})
+		return
+	}
+
+	// 请求体可以为空
+	var req AlertActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := apply(db, &a, &req, c.GetString("username"), time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// GetMaintenanceWindows 获取维护窗口，active=true 时只返回当前生效的，支持 domain_id、server_id 参数
+func GetMaintenanceWindows(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.MaintenanceWindow{})
+	if c.Query("active") == "true" {
+		now := time.Now()
+		query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+	}
+	for _, field := range []string{"domain_id", "server_id"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+
+	windows := []model.MaintenanceWindow{}
+	if err := query.Order("starts_at DESC").Limit(500).Find(&windows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取维护窗口失败"})
+		return
+	}
+	c.JSON(http.StatusOK, windows)
+}
+
+// AddMaintenanceWindow 添加维护窗口
+func AddMaintenanceWindow(c *gin.Context) {
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	window := model.MaintenanceWindow{CreatedBy: c.GetString("username")}
+	if msg := applyMaintenanceWindowRequest(db, &window, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := db.Create(&window).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加维护窗口失败"})
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
+
+// UpdateMaintenanceWindow 更新维护窗口，可用于提前结束或延长维护
+func UpdateMaintenanceWindow(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var window model.MaintenanceWindow
+	if err := db.First(&window, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "维护窗口不存在"})
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	if msg := applyMaintenanceWindowRequest(db, &window, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := db.Save(&window).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新维护窗口失败"})
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
+
+// DeleteMaintenanceWindow 删除维护窗口
+func DeleteMaintenanceWindow(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Delete(&model.MaintenanceWindow{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除维护窗口失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// applyMaintenanceWindowRequest 校验请求并写入维护窗口，返回错误信息
+func applyMaintenanceWindowRequest(db *gorm.DB, window *model.MaintenanceWindow, req *MaintenanceWindowRequest) string {
+	if (req.DomainID == 0) == (req.ServerID == 0) {
+		return "domainId 与 serverId 必须且只能指定一个"
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return "结束时间必须晚于开始时间"
+	}
+	if req.DomainID != 0 {
+		if err := db.First(&model.Domain{}, req.DomainID).Error; err != nil {
+			return "域名不存在"
+		}
+	} else if err := db.First(&model.Server{}, req.ServerID).Error; err != nil {
+		return "服务器不存在"
+	}
+
+	window.DomainID = req.DomainID
+	window.ServerID = req.ServerID
+	window.StartsAt = req.StartsAt
+	window.EndsAt = req.EndsAt
+	window.Reason = req.Reason
+	return ""
+}
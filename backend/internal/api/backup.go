@@ -1,27 +1,179 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/config"
 	"github.com/go-ssl-monitor/internal/model"
 	"gorm.io/gorm"
-	"github.com/go-ssl-monitor/internal/config"
-	"github.com/go-ssl-monitor/internal/email"
 )
 
-// GetBackupLogs 获取所有备份日志
+// 备份日志分页默认值与上限
+const (
+	defaultBackupLogPageSize = 20
+	maxBackupLogPageSize     = 200
+)
+
+// backupLogSortFields 允许排序的字段
+var backupLogSortFields = map[string]bool{
+	"id":               true,
+	"ip":               true,
+	"server_name":      true,
+	"start_time":       true,
+	"end_time":         true,
+	"duration_seconds": true,
+	"backup_status":    true,
+	"alert_status":     true,
+	"script_version":   true,
+}
+
+// GetBackupLogs 分页查询备份日志
+// 查询参数：q（IP/服务器/版本模糊搜索）、ip（单个地址或 CIDR 网段）、server_id、server_name、
+// backup_status、alert_status（可逗号分隔多个值）、
+// script_version、start_from/start_to（与运行时间段有重叠）、page、page_size、sort、order
 func GetBackupLogs(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.BackupLog{})
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("ip LIKE ? OR server_name LIKE ? OR script_version LIKE ?", like, like, like)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		if strings.Contains(ip, "/") {
+			lo, hi, err := model.IPRange(ip)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+				return
+			}
+			query = query.Where("ip_bin BETWEEN ? AND ?", lo, hi)
+		} else {
+			normalized, err := model.NormalizeIP(ip)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+				return
+			}
+			query = query.Where("ip = ?", normalized)
+		}
+	}
+	if serverID := c.Query("server_id"); serverID != "" {
+		query = query.Where("server_id = ?", serverID)
+	}
+	if serverName := c.Query("server_name"); serverName != "" {
+		query = query.Where("server_name LIKE ?", "%"+serverName+"%")
+	}
+	if version := c.Query("script_version"); version != "" {
+		query = query.Where("script_version = ?", version)
+	}
+	for _, field := range []string{"backup_status", "alert_status"} {
+		if v := c.Query(field); v != "" {
+			values, err := parseIntList(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的" + field + "参数"})
+				return
+			}
+			query = query.Where(field+" IN ?", values)
+		}
+	}
+	if v := c.Query("start_from"); v != "" {
+		from, err := model.ParseBackupTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的start_from参数"})
+			return
+		}
+		query = query.Where("COALESCE(end_time, start_time) >= ?", from)
+	}
+	if v := c.Query("start_to"); v != "" {
+		to, err := model.ParseBackupTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的start_to参数"})
+			return
+		}
+		query = query.Where("start_time <= ?", to)
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的page参数"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultBackupLogPageSize)))
+	if err != nil || pageSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的page_size参数"})
+		return
+	}
+	if pageSize > maxBackupLogPageSize {
+		pageSize = maxBackupLogPageSize
+	}
+
+	sortField := c.DefaultQuery("sort", "id")
+	if !backupLogSortFields[sortField] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的排序字段"})
+		return
+	}
+	order := strings.ToUpper(c.DefaultQuery("order", "desc"))
+	if order != "ASC" && order != "DESC" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的order参数"})
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份日志失败"})
+		return
+	}
+
+	logs := []model.BackupLog{}
+	orderBy := sortField + " " + order
+	if sortField != "id" {
+		orderBy += ", id " + order
+	}
+	if err := query.Order(orderBy).Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     logs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetAllBackupLogs 获取所有备份日志，供原 backup_api 路由使用；使用摄取令牌时只返回令牌对应服务器的记录
+func GetAllBackupLogs(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Order("id DESC")
+	if token := agentTokenFromContext(c); token != nil {
+		query = query.Where("ip = ?", token.Ip)
+	}
+
 	var logs []model.BackupLog
-	
-	if err := db.Order("Id DESC").Find(&logs).Error; err != nil {
+	if err := query.Find(&logs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份日志失败"})
 		return
 	}
-	
 	c.JSON(http.StatusOK, logs)
 }
 
+// parseIntList 解析逗号分隔的整数列表
+func parseIntList(s string) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
 // CreateBackupLog 创建备份日志
 func CreateBackupLog(c *gin.Context) {
 	var log model.BackupLog
@@ -30,21 +182,61 @@ func CreateBackupLog(c *gin.Context) {
 		return
 	}
 
+	// 告警状态由服务端告警策略在备份结束时决定，输出通过 UpdateBackupLog 或 UploadBackupOutput 上报
+	log.AlertStatus = model.AlertStatusNone
+	log.ErrorExcerpt = ""
+	log.HasOutput = false
+
+	// 使用摄取令牌时，记录归属于令牌所属的服务器，而不是客户端上报的地址
+	if token := agentTokenFromContext(c); token != nil {
+		log.Ip = token.Ip
+		if token.ServerName != "" {
+			log.ServerName = token.ServerName
+		}
+	}
+	ip, err := model.NormalizeIP(log.Ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Ip = ip
+
 	db := c.MustGet("db").(*gorm.DB)
+	log.ServerID = serverIDForIP(db, log.Ip)
 	if err := db.Create(&log).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建备份日志失败"})
 		return
 	}
 
+	checkScriptDowngrade(db, &log)
+
 	c.JSON(http.StatusOK, log)
 }
 
-// UpdateBackupLog 更新备份日志
+// BackupLogUpdateRequest 备份结束时上报的数据，除备份日志字段外可附带脚本输出
+type BackupLogUpdateRequest struct {
+	model.BackupLog
+	Output string `json:"output"` // 脚本输出（通常为末尾部分），超过上限时只保留末尾
+}
+
+// updateBodyHeadroom 更新请求体除脚本输出外的其他字段及 JSON 结构所需的余量
+const updateBodyHeadroom = 64 << 10
+
+// UpdateBackupLog 更新备份日志。附带的脚本输出超过保存上限时只保留末尾部分，状态等字段照常更新；
+// 请求体上限为输出上限的两倍（容纳 JSON 转义）加固定余量，更大的输出应通过 POST /backupLogs/:id/output 上传
 func UpdateBackupLog(c *gin.Context) {
 	id := c.Param("id")
-	var log model.BackupLog
-	
-	if err := c.ShouldBindJSON(&log); err != nil {
+	var req BackupLogUpdateRequest
+	log := &req.BackupLog
+
+	bodyLimit := 2*int64(config.AppConfig.Backup.MaxOutputBytesLimit()) + updateBodyHeadroom
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, bodyLimit)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "请求数据过大，脚本输出请通过输出上传接口提交"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
@@ -58,26 +250,49 @@ func UpdateBackupLog(c *gin.Context) {
 		return
 	}
 
-	// 如果备份状态为异常且之前未发送告警，尝试发送告警邮件
-	if log.BackupStatus == 1 && originalLog.AlertStatus == 0 {
-		emailSender := email.NewEmailSender(&config.AppConfig.Email)
-		err := emailSender.SendAlertEmail(originalLog.Ip, originalLog.ServerName, "备份执行失败")
-		
-		if err != nil {
-			// 邮件发送失败，设置告警状态为未触发
-			log.AlertStatus = 2
-		} else {
-			// 邮件发送成功，设置告警状态为已触发
-			log.AlertStatus = 1
+	if token := agentTokenFromContext(c); token != nil && originalLog.Ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权更新其他服务器的备份日志"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if log.ExitCode != nil {
+		originalLog.ExitCode = log.ExitCode
+		updates["exit_code"] = *log.ExitCode
+	}
+	if log.BytesWritten > 0 {
+		originalLog.BytesWritten = log.BytesWritten
+		updates["bytes_written"] = log.BytesWritten
+	}
+	if log.Checksum != "" {
+		originalLog.Checksum = log.Checksum
+		updates["checksum"] = log.Checksum
+	}
+	// 先保存输出，使告警邮件能包含输出摘录
+	if req.Output != "" {
+		if err := saveBackupOutput(db, &originalLog, []byte(req.Output), int64(len(req.Output))); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存备份输出失败"})
+			return
 		}
 	}
 
+	// 告警状态由服务端的告警策略决定，忽略客户端上报的值；
+	// 只在备份首次结束时执行策略，重复上报不会重复告警
+	log.AlertStatus = originalLog.AlertStatus
+	if originalLog.EndTime.IsZero() && !log.EndTime.IsZero() {
+		log.AlertStatus = applyBackupAlertPolicy(db, &originalLog, log.BackupStatus)
+	}
+
+	// 根据原开始时间和新结束时间计算耗时
+	log.StartTime = originalLog.StartTime
+	log.ComputeDuration()
+
 	// 更新记录
-	result := db.Model(&model.BackupLog{}).Where("Id = ?", id).Updates(map[string]interface{}{
-		"EndTime": log.EndTime,
-		"BackupStatus": log.BackupStatus,
-		"AlertStatus": log.AlertStatus,
-	})
+	updates["end_time"] = log.EndTime
+	updates["duration_seconds"] = log.DurationSeconds
+	updates["backup_status"] = log.BackupStatus
+	updates["alert_status"] = log.AlertStatus
+	result := db.Model(&model.BackupLog{}).Where("id = ?", id).Updates(updates)
 
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新备份日志失败"})
@@ -92,13 +307,43 @@ func UpdateBackupLog(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
 
-// GetLastBackupLogByIP 根据IP获取最后一条备份记录
+// DeleteBackupLog 删除备份日志
+func DeleteBackupLog(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var backupLog model.BackupLog
+	if err := db.First(&backupLog, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到指定的备份日志"})
+		return
+	}
+	if token := agentTokenFromContext(c); token != nil && backupLog.Ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除其他服务器的备份日志"})
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return model.DeleteBackupLogs(tx, []uint{backupLog.Id})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除备份日志失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetLastBackupLogByIP 根据IP获取最后一条备份记录，供备份脚本使用；界面请使用 GetServerSummary
 func GetLastBackupLogByIP(c *gin.Context) {
-	ip := c.Param("ip")
+	ip, err := model.NormalizeIP(c.Param("ip"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if token := agentTokenFromContext(c); token != nil && ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查询其他服务器的备份记录"})
+		return
+	}
 	var log model.BackupLog
 	
 	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Where("Ip = ?", ip).Order("Id DESC").First(&log).Error; err != nil {
+	if err := db.Where("ip = ?", ip).Order("id DESC").First(&log).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "未找到备份记录"})
 		return
 	}
@@ -106,13 +351,21 @@ func GetLastBackupLogByIP(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"id": log.Id})
 }
 
-// GetBackupStatusByIP 根据IP获取最后一条备份状态
+// GetBackupStatusByIP 根据IP获取最后一条备份状态，供备份脚本使用；界面请使用 GetServerSummary
 func GetBackupStatusByIP(c *gin.Context) {
-	ip := c.Param("ip")
+	ip, err := model.NormalizeIP(c.Param("ip"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if token := agentTokenFromContext(c); token != nil && ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查询其他服务器的备份记录"})
+		return
+	}
 	var log model.BackupLog
 	
 	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Where("Ip = ?", ip).Order("Id DESC").First(&log).Error; err != nil {
+	if err := db.Where("ip = ?", ip).Order("id DESC").First(&log).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "未找到备份记录"})
 		return
 	}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// applyBackupAlertPolicy 根据告警策略处理一次结束的备份，返回应记录的 AlertStatus
+// 连续失败达到阈值时告警，达到升级阈值时向升级收件人告警，恢复成功时发送恢复通知，同一级别不重复告警。
+// 告警已确认、暂停通知或服务器处于维护窗口时只记录告警，不发送通知，告警级别也不提升，
+// 之后每次失败继续提交告警，暂停到期或维护窗口结束后再通知。
+// 发送失败的通知保留在发件箱中重试，最终发送成功后备份日志的 AlertStatus 会被更新
+func applyBackupAlertPolicy(db *gorm.DB, backupLog *model.BackupLog, backupStatus int) int {
+	policy := config.AppConfig.Backup.AlertPolicy
+
+	var state model.BackupAlertState
+	if err := db.Where("ip = ?", backupLog.Ip).First(&state).Error; err != nil {
+		state = model.BackupAlertState{Ip: backupLog.Ip}
+	}
+
+	alertStatus := model.AlertStatusNone
+	escalationTo := append(append([]string{}, config.AppConfig.Email.ToAddresses...), policy.EscalationRecipients...)
+
+	if backupStatus != 0 {
+		state.ConsecutiveFailures++
+		state.LastFailureLogID = backupLog.Id
+		message := fmt.Sprintf("备份执行失败（连续失败 %d 次）", state.ConsecutiveFailures)
+		if backupLog.ExitCode != nil {
+			message += fmt.Sprintf("\n退出码: %d", *backupLog.ExitCode)
+		}
+		if backupLog.ErrorExcerpt != "" {
+			message += "\n\n脚本输出（末尾部分）:\n" + backupLog.ErrorExcerpt
+		}
+
+		switch {
+		case policy.EscalateAfter > 0 && state.ConsecutiveFailures >= policy.EscalateAfter && state.Level < model.AlertLevelEscalated:
+			alertStatus = model.AlertStatusSilenced
+			if a, notify := alert.Raise(db, failureCondition(backupLog, "backup_escalation", model.SeverityCritical, message), time.Now()); notify {
+				msg := email.BackupAlertMessage(db, backupLog.Ip, backupLog.ServerName, "[升级] "+message)
+				alertStatus = deliverBackupNotice(db, backupLog, alert.Message(db, a), escalationTo, msg, model.AlertStatusEscalated)
+				state.LastNotifiedAt = time.Now()
+				state.Level = model.AlertLevelEscalated
+			}
+		case state.ConsecutiveFailures >= policy.AlertThreshold() && state.Level < model.AlertLevelAlerted:
+			alertStatus = model.AlertStatusSilenced
+			if a, notify := alert.Raise(db, failureCondition(backupLog, "backup_alert", model.SeverityWarning, message), time.Now()); notify {
+				msg := email.BackupAlertMessage(db, backupLog.Ip, backupLog.ServerName, message)
+				alertStatus = deliverBackupNotice(db, backupLog, alert.Message(db, a), nil, msg, model.AlertStatusSent)
+				state.LastNotifiedAt = time.Now()
+				state.Level = model.AlertLevelAlerted
+			}
+		default:
+			alertStatus = model.AlertStatusSuppressed
+		}
+	} else {
+		resolved := alert.Resolve(db, alert.BackupFailureKey(backupLog.Ip), time.Now())
+		target := alert.Target{ServerID: backupLog.ServerID, Ip: backupLog.Ip}
+		_, inMaintenance := alert.InMaintenance(db, target, time.Now())
+		if state.Level > model.AlertLevelNone && !policy.DisableRecovery && !inMaintenance {
+			var to []string
+			if state.Level == model.AlertLevelEscalated {
+				to = escalationTo
+			}
+			msg := email.BackupRecoveryMessage(db, backupLog.Ip, backupLog.ServerName, state.ConsecutiveFailures)
+			recovery := outbox.Message{Kind: "backup_recovery", Severity: model.SeverityInfo, Labels: alert.LabelsFor(db, target),
+				ServerID: backupLog.ServerID, Ip: backupLog.Ip}
+			if resolved != nil {
+				recovery.AlertID = resolved.ID
+			}
+			alertStatus = deliverBackupNotice(db, backupLog, recovery, to, msg, model.AlertStatusRecovered)
+			state.LastNotifiedAt = time.Now()
+		}
+		state.ConsecutiveFailures = 0
+		state.Level = model.AlertLevelNone
+	}
+
+	if err := db.Save(&state).Error; err != nil {
+		log.Printf("Failed to save backup alert state for %s: %v", backupLog.Ip, err)
+	}
+	return alertStatus
+}
+
+// failureCondition 备份连续失败的告警条件，告警和升级告警使用同一条件
+func failureCondition(backupLog *model.BackupLog, kind, severity, message string) alert.Condition {
+	return alert.Condition{
+		Key:      alert.BackupFailureKey(backupLog.Ip),
+		Kind:     kind,
+		Severity: severity,
+		Message:  message,
+		Target:   alert.Target{ServerID: backupLog.ServerID, Ip: backupLog.Ip},
+	}
+}
+
+// deliverBackupNotice 填写收件人和内容后通过发件箱发送备份通知。至少一个渠道发送成功，或失败的渠道均未启用时返回 sentStatus；
+// 其他情况下立即发送失败时返回 AlertStatusFailed，消息会在后台重试
+func deliverBackupNotice(db *gorm.DB, backupLog *model.BackupLog, m outbox.Message, to []string, msg email.Message, sentStatus int) int {
+	m.To = to
+	m.Subject, m.Body, m.HTML = msg.Subject, msg.Text, msg.HTML
+	m.BackupLogID = backupLog.Id
+	m.SentAlertStatus = sentStatus
+	messages, err := outbox.Deliver(db, m)
+	if err == nil {
+		return sentStatus
+	}
+	for _, sent := range messages {
+		if sent.Status == model.OutboxSent {
+			log.Printf("Sent %s for %s, some channels failed and are queued for retry: %v", m.Kind, backupLog.Ip, err)
+			return sentStatus
+		}
+	}
+	if onlyDisabled(err) {
+		return sentStatus
+	}
+	log.Printf("Failed to send %s for %s, queued for retry: %v", m.Kind, backupLog.Ip, err)
+	return model.AlertStatusFailed
+}
+
+// onlyDisabled 判断 outbox.Deliver 返回的各渠道错误是否都是渠道未启用，此类消息标记为跳过，不会重试
+func onlyDisabled(err error) bool {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return errors.Is(err, email.ErrDisabled)
+	}
+	for _, e := range joined.Unwrap() {
+		if !errors.Is(e, email.ErrDisabled) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetBackupAlertStates 获取各服务器当前的连续失败次数和告警级别
+func GetBackupAlertStates(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var states []model.BackupAlertState
+	if err := db.Order("ip ASC").Find(&states).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取告警状态失败"})
+		return
+	}
+	c.JSON(http.StatusOK, states)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// 计算异常耗时所需的最少历史样本数
+const minAnomalySamples = 3
+
+// BackupAnomaly 耗时明显超过该服务器常规耗时的一次备份
+type BackupAnomaly struct {
+	Id              uint             `json:"id"`
+	StartTime       model.BackupTime `json:"start_time"`
+	DurationSeconds int64            `json:"duration_seconds"`
+	Ratio           float64          `json:"ratio"` // 本次耗时 / 中位耗时
+}
+
+// BackupServerStats 单台服务器的备份统计
+type BackupServerStats struct {
+	Ip                string          `json:"ip"`
+	ServerName        string          `json:"server_name"`
+	Runs              int             `json:"runs"`
+	Finished          int             `json:"finished"`
+	Succeeded         int             `json:"succeeded"`
+	SuccessRate       float64         `json:"success_rate"`
+	AvgDuration       float64         `json:"avg_duration_seconds"`
+	MedianDuration    float64         `json:"median_duration_seconds"`
+	P95Duration       float64         `json:"p95_duration_seconds"`
+	Anomalies         []BackupAnomaly `json:"anomalies"`
+	durations         []int64
+	durationsWithLogs []model.BackupLog
+}
+
+// GetBackupAnalytics 按服务器统计备份耗时和成功率，并找出耗时异常的备份
+// 查询参数 days（默认30）为统计的天数，factor（默认3）为判定异常的耗时倍数
+func GetBackupAnalytics(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的days参数"})
+		return
+	}
+	factor, err := strconv.ParseFloat(c.DefaultQuery("factor", "3"), 64)
+	if err != nil || factor <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的factor参数"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var logs []model.BackupLog
+	since := time.Now().AddDate(0, 0, -days)
+	if err := db.Where("start_time >= ?", since).Order("start_time ASC").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份日志失败"})
+		return
+	}
+
+	byServer := make(map[string]*BackupServerStats)
+	var order []string
+	for _, l := range logs {
+		stats, ok := byServer[l.Ip]
+		if !ok {
+			stats = &BackupServerStats{Ip: l.Ip, Anomalies: []BackupAnomaly{}}
+			byServer[l.Ip] = stats
+			order = append(order, l.Ip)
+		}
+		stats.ServerName = l.ServerName
+		stats.Runs++
+		if l.EndTime.IsZero() {
+			continue
+		}
+		stats.Finished++
+		if l.BackupStatus == 0 {
+			stats.Succeeded++
+		}
+		if l.DurationSeconds > 0 {
+			stats.durations = append(stats.durations, l.DurationSeconds)
+			stats.durationsWithLogs = append(stats.durationsWithLogs, l)
+		}
+	}
+
+	result := make([]*BackupServerStats, 0, len(order))
+	for _, ip := range order {
+		stats := byServer[ip]
+		if stats.Finished > 0 {
+			stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Finished)
+		}
+		if len(stats.durations) > 0 {
+			sorted := append([]int64(nil), stats.durations...)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			var total int64
+			for _, d := range sorted {
+				total += d
+			}
+			stats.AvgDuration = float64(total) / float64(len(sorted))
+			stats.MedianDuration = percentile(sorted, 50)
+			stats.P95Duration = percentile(sorted, 95)
+
+			if len(sorted) >= minAnomalySamples && stats.MedianDuration > 0 {
+				for _, l := range stats.durationsWithLogs {
+					ratio := float64(l.DurationSeconds) / stats.MedianDuration
+					if ratio >= factor {
+						stats.Anomalies = append(stats.Anomalies, BackupAnomaly{
+							Id:              l.Id,
+							StartTime:       l.StartTime,
+							DurationSeconds: l.DurationSeconds,
+							Ratio:           math.Round(ratio*100) / 100,
+						})
+					}
+				}
+			}
+		}
+		result = append(result, stats)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":   since.Format(model.BackupTimeLayout),
+		"servers": result,
+	})
+}
+
+// percentile 计算已排序样本的百分位数（最近秩法）
+func percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(sorted[rank])
+}
+
+// GetBackupRollups 查询已归档备份日志的每日汇总
+// 查询参数：ip、server_id、from、to（日期，如 2025-01-01）
+func GetBackupRollups(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.BackupDailyRollup{})
+
+	if ip := c.Query("ip"); ip != "" {
+		normalized, err := model.NormalizeIP(ip)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+			return
+		}
+		query = query.Where("ip = ?", normalized)
+	}
+	if serverID := c.Query("server_id"); serverID != "" {
+		query = query.Where("server_id = ?", serverID)
+	}
+	for param, cond := range map[string]string{"from": "day >= ?", "to": "day <= ?"} {
+		if v := c.Query(param); v != "" {
+			day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的" + param + "参数"})
+				return
+			}
+			query = query.Where(cond, day)
+		}
+	}
+
+	rollups := []model.BackupDailyRollup{}
+	if err := query.Order("day DESC, ip ASC").Limit(5000).Find(&rollups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份汇总失败"})
+		return
+	}
+	c.JSON(http.StatusOK, rollups)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// 告警邮件中输出摘录的行数和长度上限
+const (
+	excerptLines    = 20
+	excerptMaxBytes = 2000
+)
+
+// saveBackupOutput 压缩保存备份脚本输出，超过大小上限时只保留末尾部分，并更新备份日志的输出摘录
+func saveBackupOutput(db *gorm.DB, backupLog *model.BackupLog, output []byte, originalSize int64) error {
+	record := model.BackupLogOutput{BackupLogID: backupLog.Id, OriginalSize: originalSize}
+	if limit := config.AppConfig.Backup.MaxOutputBytesLimit(); len(output) > limit {
+		output = output[len(output)-limit:]
+	}
+	output = bytes.ToValidUTF8(output, []byte("?"))
+	record.StoredSize = int64(len(output))
+	record.Truncated = record.StoredSize < originalSize
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(output); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	record.Content = buf.Bytes()
+
+	backupLog.ErrorExcerpt = outputExcerpt(string(output))
+	backupLog.HasOutput = true
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("backup_log_id = ?", backupLog.Id).Delete(&model.BackupLogOutput{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.BackupLog{}).Where("id = ?", backupLog.Id).UpdateColumns(map[string]interface{}{
+			"error_excerpt": backupLog.ErrorExcerpt,
+			"has_output":    true,
+		}).Error
+	})
+}
+
+// outputExcerpt 返回输出的最后几行，用于告警邮件
+func outputExcerpt(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	if len(lines) > excerptLines {
+		lines = lines[len(lines)-excerptLines:]
+	}
+	excerpt := strings.Join(lines, "\n")
+	if len(excerpt) > excerptMaxBytes {
+		excerpt = strings.ToValidUTF8(excerpt[len(excerpt)-excerptMaxBytes:], "")
+	}
+	return excerpt
+}
+
+// readTail 读取全部内容但只保留最后 limit 字节，返回保留的内容和总字节数
+func readTail(r io.Reader, limit int) ([]byte, int64, error) {
+	var (
+		buf   []byte
+		total int64
+		chunk = make([]byte, 32*1024)
+	)
+	for {
+		n, err := r.Read(chunk)
+		total += int64(n)
+		buf = append(buf, chunk[:n]...)
+		if len(buf) > 2*limit {
+			buf = append([]byte(nil), buf[len(buf)-limit:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, total, err
+		}
+	}
+	if len(buf) > limit {
+		buf = buf[len(buf)-limit:]
+	}
+	return buf, total, nil
+}
+
+// UploadBackupOutput 上传备份脚本输出，请求体为原始文本；应在上报结束时间之前上传，告警邮件才会包含输出摘录
+func UploadBackupOutput(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var backupLog model.BackupLog
+	if err := db.First(&backupLog, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到指定的备份日志"})
+		return
+	}
+	if token := agentTokenFromContext(c); token != nil && backupLog.Ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权更新其他服务器的备份日志"})
+		return
+	}
+
+	output, size, err := readTail(c.Request.Body, config.AppConfig.Backup.MaxOutputBytesLimit())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求数据失败"})
+		return
+	}
+	if err := saveBackupOutput(db, &backupLog, output, size); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存备份输出失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "上传成功", "error_excerpt": backupLog.ErrorExcerpt})
+}
+
+// GetBackupOutput 获取备份脚本输出，以纯文本返回；截断时响应头 X-Output-Truncated 为 true
+func GetBackupOutput(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var record model.BackupLogOutput
+	if err := db.Where("backup_log_id = ?", c.Param("id")).First(&record).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该备份没有保存输出"})
+		return
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(record.Content))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取备份输出失败"})
+		return
+	}
+	output, err := io.ReadAll(gz)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取备份输出失败"})
+		return
+	}
+
+	if record.Truncated {
+		c.Header("X-Output-Truncated", "true")
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", output)
+}
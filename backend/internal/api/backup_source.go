@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// GetBackupSources 获取所有预期的备份来源
+func GetBackupSources(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var sources []model.BackupSource
+	if err := db.Order("id ASC").Find(&sources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份来源失败"})
+		return
+	}
+	c.JSON(http.StatusOK, sources)
+}
+
+// 新登记的备份来源未指定时使用的宽限期和最长耗时（分钟）
+const (
+	defaultStartGrace  = 30
+	defaultMaxDuration = 240
+)
+
+// BackupSourceRequest 登记或更新备份来源的请求，更新时未提供的字段保持不变
+type BackupSourceRequest struct {
+	Ip            string  `json:"ip"` // 只在登记时使用
+	ServerName    *string `json:"serverName"`
+	Schedule      *string `json:"schedule"`
+	StartGrace    *int    `json:"startGrace"`
+	MaxDuration   *int    `json:"maxDuration"`
+	Enabled       *bool   `json:"enabled"`
+	RestoreMaxAge *int    `json:"restoreMaxAge"`
+}
+
+// apply 校验请求中提供的字段并写入备份来源
+func (req *BackupSourceRequest) apply(source *model.BackupSource) error {
+	if req.Schedule != nil {
+		if _, err := cron.ParseStandard(*req.Schedule); err != nil {
+			return errors.New("无效的cron表达式")
+		}
+		source.Schedule = *req.Schedule
+	}
+	if req.StartGrace != nil {
+		if *req.StartGrace <= 0 {
+			return errors.New("开始宽限期必须大于0")
+		}
+		source.StartGrace = *req.StartGrace
+	}
+	if req.MaxDuration != nil {
+		if *req.MaxDuration <= 0 {
+			return errors.New("最长耗时必须大于0")
+		}
+		source.MaxDuration = *req.MaxDuration
+	}
+	if req.RestoreMaxAge != nil {
+		if *req.RestoreMaxAge < 0 {
+			return errors.New("恢复验证间隔不能小于0")
+		}
+		source.RestoreMaxAge = *req.RestoreMaxAge
+	}
+	if req.ServerName != nil {
+		source.ServerName = *req.ServerName
+	}
+	if req.Enabled != nil {
+		source.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// AddBackupSource 登记新的备份来源，未指定时启用，宽限期和最长耗时使用默认值
+func AddBackupSource(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var req BackupSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Ip == "" || req.Schedule == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	ip, err := model.NormalizeIP(req.Ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	source := model.BackupSource{
+		Ip:          ip,
+		StartGrace:  defaultStartGrace,
+		MaxDuration: defaultMaxDuration,
+		Enabled:     true,
+	}
+	if err := req.apply(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing model.BackupSource
+	if err := db.Where("ip = ?", source.Ip).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "备份来源已存在"})
+		return
+	}
+
+	if err := db.Create(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加备份来源失败"})
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// UpdateBackupSource 更新备份来源的计划，只修改请求中提供的字段
+func UpdateBackupSource(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var source model.BackupSource
+	if err := db.First(&source, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "备份来源不存在"})
+		return
+	}
+
+	var req BackupSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	if err := req.apply(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新备份来源失败"})
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteBackupSource 删除备份来源
+func DeleteBackupSource(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	if err := db.Delete(&model.BackupSource{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除备份来源失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
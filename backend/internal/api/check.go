@@ -0,0 +1,287 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"github.com/go-ssl-monitor/pkg/ssl"
+	"gorm.io/gorm"
+)
+
+// domainCheck 一次域名检查的结果
+type domainCheck struct {
+	Findings  []model.DomainFinding
+	HTTPCheck *model.HTTPCheck
+}
+
+// HSTS max-age 低于半年时给出警告
+const minHSTSMaxAge = 180 * 24 * 3600
+
+// checkDomain 检查域名证书及附加项目，更新域名的证书字段并返回本次检查的结果
+func checkDomain(db *gorm.DB, domain *model.Domain) (*domainCheck, error) {
+	certInfo, err := ssl.CheckCertificate(domain.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domainCheck{}
+	result.Findings = append(result.Findings, checkDANE(domain)...)
+	result.Findings = append(result.Findings, checkPins(db, domain, certInfo)...)
+	if domain.HTTPCheckEnabled {
+		var findings []model.DomainFinding
+		result.HTTPCheck, findings = checkHTTP(db, domain)
+		result.Findings = append(result.Findings, findings...)
+	}
+
+	domain.CertificateStatus = domainStatus(certInfo.IsValid, result.Findings)
+	domain.CertificateIssuer = certInfo.Issuer
+	domain.CertificateExpiryDate = certInfo.NotAfter
+	domain.CertificateFingerprint = certInfo.Fingerprint
+	domain.CertificateSANs = strings.Join(certInfo.SANs, ",")
+	domain.LastChecked = time.Now()
+
+	return result, nil
+}
+
+// domainStatus 根据证书有效性和检查发现计算域名状态
+func domainStatus(certValid bool, findings []model.DomainFinding) string {
+	status := "VALID"
+	for _, f := range findings {
+		switch f.Severity {
+		case model.SeverityCritical:
+			return "ERROR"
+		case model.SeverityWarning:
+			status = "WARNING"
+		}
+	}
+	if !certValid {
+		return "ERROR"
+	}
+	return status
+}
+
+// saveCheckResults 保存本次检查结果，发现会替换域名原有的发现
+func saveCheckResults(db *gorm.DB, domain *model.Domain, result *domainCheck) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("domain_id = ?", domain.ID).Delete(&model.DomainFinding{}).Error; err != nil {
+			return err
+		}
+		if len(result.Findings) > 0 {
+			for i := range result.Findings {
+				result.Findings[i].DomainID = domain.ID
+			}
+			if err := tx.Create(&result.Findings).Error; err != nil {
+				return err
+			}
+		}
+		if result.HTTPCheck != nil {
+			result.HTTPCheck.DomainID = domain.ID
+			if err := tx.Save(result.HTTPCheck).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkDANE 对域名配置的端口进行 DANE/TLSA 校验
+func checkDANE(domain *model.Domain) []model.DomainFinding {
+	var findings []model.DomainFinding
+	// TLSA 记录名及连接端口取自 tlsa_ports，监控地址中的端口需要去掉
+	host := ssl.Hostname(domain.DomainName)
+	for _, p := range strings.Split(domain.TLSAPorts, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			findings = append(findings, model.DomainFinding{
+				Check:    "dane",
+				Severity: model.SeverityWarning,
+				Message:  fmt.Sprintf("无效的TLSA端口: %s", p),
+			})
+			continue
+		}
+
+		result := ssl.CheckDANE(config.AppConfig.SSL.DNSSECResolver, host, port)
+		for _, e := range result.Errors {
+			findings = append(findings, model.DomainFinding{
+				Check:    "dane",
+				Severity: model.SeverityWarning,
+				Message:  fmt.Sprintf("%s: %s", result.Name, e),
+			})
+		}
+		// 未完成比对（查询或连接失败）时只报告错误
+		if !result.Valid && !result.Mismatch {
+			continue
+		}
+		if result.Mismatch {
+			findings = append(findings, model.DomainFinding{
+				Check:    "dane",
+				Severity: model.SeverityCritical,
+				Message:  fmt.Sprintf("%s: 证书链与所有TLSA记录均不匹配", result.Name),
+			})
+			continue
+		}
+		for _, r := range result.StaleRecords {
+			findings = append(findings, model.DomainFinding{
+				Check:    "dane",
+				Severity: model.SeverityWarning,
+				Message:  fmt.Sprintf("%s: 过期的TLSA记录 %d %d %d %s", result.Name, r.Usage, r.Selector, r.MatchingType, r.Data),
+			})
+		}
+	}
+	return findings
+}
+
+// checkHTTP 执行 HTTP 阶段检查，并与上一次结果比较以发现 HSTS 或跳转被移除的情况
+func checkHTTP(db *gorm.DB, domain *model.Domain) (*model.HTTPCheck, []model.DomainFinding) {
+	var previous model.HTTPCheck
+	hasPrevious := false
+	if domain.ID != 0 {
+		hasPrevious = db.Where("domain_id = ?", domain.ID).First(&previous).Error == nil
+	}
+
+	result := ssl.CheckHTTP(domain.DomainName, domain.HTTPCheckPath)
+	headers, _ := json.Marshal(result.Headers)
+	check := &model.HTTPCheck{
+		ID:                    previous.ID,
+		URL:                   result.URL,
+		StatusCode:            result.StatusCode,
+		HSTSPresent:           result.HSTS.Present,
+		HSTSMaxAge:            result.HSTS.MaxAge,
+		HSTSIncludeSubDomains: result.HSTS.IncludeSubDomains,
+		HSTSPreload:           result.HSTS.Preload,
+		RedirectsToHTTPS:      result.RedirectsToHTTPS,
+		RedirectStatus:        result.RedirectStatus,
+		RedirectLocation:      result.RedirectLocation,
+		Headers:               string(headers),
+		CheckedAt:             time.Now(),
+		CreatedAt:             previous.CreatedAt,
+	}
+
+	var findings []model.DomainFinding
+	add := func(severity, message string) {
+		findings = append(findings, model.DomainFinding{Check: "http", Severity: severity, Message: message})
+	}
+	for _, e := range result.Errors {
+		add(model.SeverityWarning, e)
+	}
+	if result.StatusCode == 0 {
+		return check, findings
+	}
+
+	switch {
+	case !check.HSTSPresent && hasPrevious && previous.HSTSPresent:
+		add(model.SeverityCritical, "HSTS响应头已被移除")
+	case !check.HSTSPresent:
+		add(model.SeverityWarning, "未设置HSTS响应头")
+	case check.HSTSMaxAge < minHSTSMaxAge:
+		add(model.SeverityWarning, fmt.Sprintf("HSTS max-age 过短: %d", check.HSTSMaxAge))
+	}
+	if hasPrevious && previous.HSTSIncludeSubDomains && check.HSTSPresent && !check.HSTSIncludeSubDomains {
+		add(model.SeverityWarning, "HSTS已移除 includeSubDomains")
+	}
+	if hasPrevious && previous.HSTSPreload && check.HSTSPresent && !check.HSTSPreload {
+		add(model.SeverityWarning, "HSTS已移除 preload")
+	}
+
+	if result.RedirectStatus != 0 && !check.RedirectsToHTTPS {
+		if hasPrevious && previous.RedirectsToHTTPS {
+			add(model.SeverityCritical, "HTTP已不再跳转到HTTPS")
+		} else {
+			add(model.SeverityWarning, "HTTP未跳转到HTTPS")
+		}
+	}
+
+	if _, ok := result.Headers["X-Content-Type-Options"]; !ok {
+		add(model.SeverityInfo, "未设置X-Content-Type-Options响应头")
+	}
+	if csp, ok := result.Headers["Content-Security-Policy"]; !ok || !strings.Contains(csp, "upgrade-insecure-requests") && !strings.Contains(csp, "block-all-mixed-content") {
+		add(model.SeverityInfo, "Content-Security-Policy 未启用混合内容保护")
+	}
+
+	return check, findings
+}
+
+// checkPins 校验证书链是否包含域名期望的 SPKI pin，不再包含任何 pin 时发送严重告警
+func checkPins(db *gorm.DB, domain *model.Domain, certInfo *ssl.CertInfo) []model.DomainFinding {
+	if domain.ID == 0 || len(certInfo.Chain) == 0 {
+		return nil
+	}
+	var pins []model.DomainPin
+	if err := db.Where("domain_id = ?", domain.ID).Find(&pins).Error; err != nil || len(pins) == 0 {
+		return nil
+	}
+
+	if len(matchPins(pins, certInfo.Chain)) > 0 {
+		alert.Resolve(db, alert.PinMismatchKey(domain.ID), time.Now())
+		return nil
+	}
+
+	message := "证书链中不再包含任何已固定的公钥 (SPKI pin)"
+	finding := model.DomainFinding{Check: "pin", Severity: model.SeverityCritical, Message: message}
+
+	// 仅在状态由正常变为不匹配时发送告警，避免重复通知
+	var open int64
+	db.Model(&model.DomainFinding{}).
+		Where("domain_id = ? AND `check` = ? AND severity = ?", domain.ID, "pin", model.SeverityCritical).
+		Count(&open)
+	if open == 0 {
+		a, notify := alert.Raise(db, alert.Condition{
+			Key:      alert.PinMismatchKey(domain.ID),
+			Kind:     "domain_alert",
+			Severity: model.SeverityCritical,
+			Message:  message,
+			Target:   alert.Target{DomainID: domain.ID},
+		}, time.Now())
+		if !notify {
+			return []model.DomainFinding{finding}
+		}
+		msg := email.DomainAlertMessage(db, domain, message)
+		m := alert.Message(db, a)
+		m.To = domainRecipients(domain)
+		m.Subject, m.Body, m.HTML = msg.Subject, msg.Text, msg.HTML
+		_, err := outbox.Deliver(db, m)
+		if err != nil {
+			log.Printf("Failed to send pin alert for %s, queued for retry: %v", domain.DomainName, err)
+		}
+	}
+
+	return []model.DomainFinding{finding}
+}
+
+// matchPins 返回证书链能满足的 pin
+func matchPins(pins []model.DomainPin, chain []ssl.ChainCert) []model.DomainPin {
+	var matched []model.DomainPin
+	for _, pin := range pins {
+		for _, cert := range chain {
+			if cert.SPKIPin == pin.Pin {
+				matched = append(matched, pin)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// domainRecipients 解析域名的通知邮箱，多个地址以逗号分隔
+func domainRecipients(domain *model.Domain) []string {
+	var to []string
+	for _, addr := range strings.Split(domain.NotificationEmail, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
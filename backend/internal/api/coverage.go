@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/pkg/ssl"
+	"gorm.io/gorm"
+)
+
+// CertificateCoverage 一张证书及其覆盖的域名
+type CertificateCoverage struct {
+	Fingerprint      string    `json:"fingerprint"`
+	Issuer           string    `json:"issuer"`
+	ExpiryDate       time.Time `json:"expiryDate"`
+	RemainingDays    int       `json:"remainingDays"`
+	SANs             []string  `json:"sans"`
+	Wildcard         bool      `json:"wildcard"`
+	PresentedBy      []string  `json:"presentedBy"`      // 实际返回该证书的域名
+	CoveredDomains   []string  `json:"coveredDomains"`   // SAN 覆盖的所有已登记域名
+	UncoveredDomains []string  `json:"uncoveredDomains"` // 返回该证书但未被 SAN 覆盖的域名
+	Warning          string    `json:"warning,omitempty"`
+}
+
+// GetCertificateCoverage 按实际返回的证书对域名分组，报告 SAN/通配符覆盖情况
+// 查询参数 days（默认30）和 minDomains（默认3）：共享证书在 days 天内到期且影响不少于 minDomains 个域名时给出警告
+func GetCertificateCoverage(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的days参数"})
+		return
+	}
+	minDomains, err := strconv.Atoi(c.DefaultQuery("minDomains", "3"))
+	if err != nil || minDomains < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的minDomains参数"})
+		return
+	}
+
+	var domains []model.Domain
+	if err := db.Find(&domains).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取域名列表失败"})
+		return
+	}
+
+	groups := make(map[string]*CertificateCoverage)
+	var unchecked []string
+	for _, d := range domains {
+		if d.CertificateFingerprint == "" {
+			unchecked = append(unchecked, d.DomainName)
+			continue
+		}
+		g, ok := groups[d.CertificateFingerprint]
+		if !ok {
+			g = &CertificateCoverage{
+				Fingerprint:   d.CertificateFingerprint,
+				Issuer:        d.CertificateIssuer,
+				ExpiryDate:    d.CertificateExpiryDate,
+				RemainingDays: int(time.Until(d.CertificateExpiryDate).Hours() / 24),
+			}
+			for _, san := range strings.Split(d.CertificateSANs, ",") {
+				if san = strings.TrimSpace(san); san != "" {
+					g.SANs = append(g.SANs, san)
+					if strings.HasPrefix(san, "*.") {
+						g.Wildcard = true
+					}
+				}
+			}
+			groups[d.CertificateFingerprint] = g
+		}
+		g.PresentedBy = append(g.PresentedBy, d.DomainName)
+		if !ssl.CoveredBy(d.DomainName, g.SANs) {
+			g.UncoveredDomains = append(g.UncoveredDomains, d.DomainName)
+		}
+	}
+
+	certificates := make([]*CertificateCoverage, 0, len(groups))
+	var warnings []string
+	for _, g := range groups {
+		for _, d := range domains {
+			if ssl.CoveredBy(d.DomainName, g.SANs) {
+				g.CoveredDomains = append(g.CoveredDomains, d.DomainName)
+			}
+		}
+		if g.RemainingDays <= days && len(g.PresentedBy) >= minDomains {
+			g.Warning = fmt.Sprintf("证书将在%d天后到期，影响%d个域名", g.RemainingDays, len(g.PresentedBy))
+			warnings = append(warnings, fmt.Sprintf("%s (%s): %s", g.Fingerprint[:16], g.Issuer, g.Warning))
+		}
+		certificates = append(certificates, g)
+	}
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].ExpiryDate.Before(certificates[j].ExpiryDate)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificates": certificates,
+		"unchecked":    unchecked,
+		"warnings":     warnings,
+	})
+}
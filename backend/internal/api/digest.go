@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/job"
+	"gorm.io/gorm"
+)
+
+// findDigest 按名称查找配置的汇总报告
+func findDigest(name string) (config.DigestConfig, bool) {
+	for _, d := range config.AppConfig.Digests {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return config.DigestConfig{}, false
+}
+
+// DigestResponse 汇总报告的配置，天数为实际使用的值（未配置时为默认值）
+type DigestResponse struct {
+	Name       string   `json:"name"`
+	Schedule   string   `json:"schedule"`
+	Timezone   string   `json:"timezone"`
+	Recipients []string `json:"recipients"` // 为空时使用默认收件人
+	ExpiryDays int      `json:"expiryDays"`
+	PeriodDays int      `json:"periodDays"`
+}
+
+// GetDigests 获取已配置的汇总报告
+func GetDigests(c *gin.Context) {
+	digests := []DigestResponse{}
+	for _, d := range config.AppConfig.Digests {
+		recipients := d.Recipients
+		if recipients == nil {
+			recipients = []string{}
+		}
+		digests = append(digests, DigestResponse{
+			Name:       d.Name,
+			Schedule:   d.Schedule,
+			Timezone:   d.Timezone,
+			Recipients: recipients,
+			ExpiryDays: d.ExpiryWindow(),
+			PeriodDays: d.Period(),
+		})
+	}
+	c.JSON(http.StatusOK, digests)
+}
+
+// PreviewDigest 生成汇总报告并返回报告数据和渲染后的邮件，不发送
+func PreviewDigest(c *gin.Context) {
+	d, ok := findDigest(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "汇总报告不存在"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	report, err := job.BuildDigest(db, d, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成汇总报告失败: " + err.Error()})
+		return
+	}
+	msg := report.Message(db)
+	c.JSON(http.StatusOK, gin.H{
+		"report":  report,
+		"subject": msg.Subject,
+		"text":    msg.Text,
+		"html":    msg.HTML,
+	})
+}
+
+// SendDigest 立即生成并发送汇总报告
+func SendDigest(c *gin.Context) {
+	d, ok := findDigest(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "汇总报告不存在"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	if err := job.SendDigest(db, d, time.Now()); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "发送失败，已加入发件箱等待重试: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "发送成功"})
+}
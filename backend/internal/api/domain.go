@@ -2,11 +2,11 @@ package api
 
 import (
 	"net/http"
-	"time"
+	"net/mail"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-ssl-monitor/internal/model"
-	"github.com/go-ssl-monitor/pkg/ssl"
 	"gorm.io/gorm"
 )
 
@@ -30,6 +30,13 @@ func AddDomain(c *gin.Context) {
 		return
 	}
 
+	recipients, err := normalizeRecipients(domain.NotificationEmail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知邮箱: " + err.Error()})
+		return
+	}
+	domain.NotificationEmail = recipients
+
 	// 检查域名是否已存在
 	var existingDomain model.Domain
 	if err := db.Where("domain_name = ?", domain.DomainName).First(&existingDomain).Error; err == nil {
@@ -38,25 +45,22 @@ func AddDomain(c *gin.Context) {
 	}
 
 	// 检查证书状态
-	certInfo, err := ssl.CheckCertificate(domain.DomainName)
+	result, err := checkDomain(db, &domain)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "检查证书失败"})
 		return
 	}
 
-	domain.CertificateStatus = "VALID"
-	if !certInfo.IsValid {
-		domain.CertificateStatus = "ERROR"
-	}
-	domain.CertificateIssuer = certInfo.Issuer
-	domain.CertificateExpiryDate = certInfo.NotAfter
-	domain.LastChecked = time.Now()
-
 	if err := db.Create(&domain).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加域名失败"})
 		return
 	}
 
+	if err := saveCheckResults(db, &domain, result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存检查结果失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, domain)
 }
 
@@ -76,8 +80,20 @@ func UpdateDomain(c *gin.Context) {
 		return
 	}
 
-	domain.NotificationEmail = updateData.NotificationEmail
+	recipients, err := normalizeRecipients(updateData.NotificationEmail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知邮箱: " + err.Error()})
+		return
+	}
+
+	domain.NotificationEmail = recipients
+	domain.Tags = updateData.Tags
+	domain.Environment = updateData.Environment
+	domain.OwnerTeam = updateData.OwnerTeam
 	domain.AutoRenewal = updateData.AutoRenewal
+	domain.TLSAPorts = updateData.TLSAPorts
+	domain.HTTPCheckEnabled = updateData.HTTPCheckEnabled
+	domain.HTTPCheckPath = updateData.HTTPCheckPath
 
 	if err := db.Save(&domain).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新域名失败"})
@@ -91,7 +107,16 @@ func UpdateDomain(c *gin.Context) {
 func DeleteDomain(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 	id := c.Param("id")
-	if err := db.Delete(&model.Domain{}, id).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 同时删除域名关联的检查结果和 pin
+		for _, related := range []interface{}{&model.DomainFinding{}, &model.HTTPCheck{}, &model.DomainPin{}} {
+			if err := tx.Where("domain_id = ?", id).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&model.Domain{}, id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除域名失败"})
 		return
 	}
@@ -108,28 +133,49 @@ func CheckDomainCertificate(c *gin.Context) {
 		return
 	}
 
-	certInfo, err := ssl.CheckCertificate(domain.DomainName)
+	result, err := checkDomain(db, &domain)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "检查证书失败"})
 		return
 	}
 
-	domain.CertificateStatus = "VALID"
-	if !certInfo.IsValid {
-		domain.CertificateStatus = "ERROR"
-	}
-	domain.CertificateIssuer = certInfo.Issuer
-	domain.CertificateExpiryDate = certInfo.NotAfter
-	domain.LastChecked = time.Now()
-
 	if err := db.Save(&domain).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新证书状态失败"})
 		return
 	}
 
+	if err := saveCheckResults(db, &domain, result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存检查结果失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, domain)
 }
 
+// GetDomainFindings 获取域名最近一次检查的发现
+func GetDomainFindings(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	id := c.Param("id")
+	var findings []model.DomainFinding
+	if err := db.Where("domain_id = ?", id).Order("id ASC").Find(&findings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取检查结果失败"})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+// GetDomainHTTPCheck 获取域名最近一次 HTTP 安全头检查结果
+func GetDomainHTTPCheck(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	id := c.Param("id")
+	var check model.HTTPCheck
+	if err := db.Where("domain_id = ?", id).First(&check).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到HTTP检查结果"})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
 // ToggleAutoRenewal 切换自动续期状态
 func ToggleAutoRenewal(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
@@ -147,4 +193,26 @@ func ToggleAutoRenewal(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, domain)
-} 
\ No newline at end of file
+} 
+// normalizeRecipients 校验以逗号、分号或换行分隔的通知邮箱，返回逗号分隔的地址列表
+func normalizeRecipients(list string) (string, error) {
+	list = strings.NewReplacer(";", ",", "\n", ",").Replace(list)
+	var parts []string
+	for _, part := range strings.Split(list, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	addrs, err := mail.ParseAddressList(strings.Join(parts, ","))
+	if err != nil {
+		return "", err
+	}
+	normalized := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		normalized = append(normalized, a.Address)
+	}
+	return strings.Join(normalized, ","), nil
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+type EmailTemplateRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Text    string `json:"text" binding:"required"`
+	HTML    string `json:"html"`
+}
+
+// EmailTemplatePreviewRequest 预览请求，未提供模板内容时预览当前生效的模板
+type EmailTemplatePreviewRequest struct {
+	Name    string                 `json:"name" binding:"required"`
+	Lang    string                 `json:"lang"`
+	Subject string                 `json:"subject"`
+	Text    string                 `json:"text"`
+	HTML    string                 `json:"html"`
+	Data    map[string]interface{} `json:"data"` // 覆盖示例数据中的变量
+}
+
+// GetEmailTemplates 获取各模板各语言当前生效的内容及来源（database、file、builtin）
+func GetEmailTemplates(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	templates := []*email.Template{}
+	for _, name := range email.TemplateNames {
+		for _, lang := range email.Languages {
+			t, err := email.LoadTemplate(db, name, lang)
+			if err != nil || t.Lang != lang {
+				continue
+			}
+			templates = append(templates, t)
+		}
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// SaveEmailTemplate 保存自定义模板，覆盖模板目录和内置模板
+func SaveEmailTemplate(c *gin.Context) {
+	name, lang := c.Param("name"), c.Param("lang")
+	if !validTemplate(name, lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的模板名称或语言"})
+		return
+	}
+	var req EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	t := &email.Template{Name: name, Lang: lang, Subject: req.Subject, Text: req.Text, HTML: req.HTML}
+	if err := t.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板渲染失败: " + err.Error()})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var record model.NotificationTemplate
+	db.Where("name = ? AND lang = ?", name, lang).First(&record)
+	record.Name = name
+	record.Lang = lang
+	record.Subject = req.Subject
+	record.Text = req.Text
+	record.HTML = req.HTML
+	if err := db.Save(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存模板失败"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteEmailTemplate 删除自定义模板，恢复使用模板目录或内置模板
+func DeleteEmailTemplate(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	err := db.Where("name = ? AND lang = ?", c.Param("name"), c.Param("lang")).Delete(&model.NotificationTemplate{}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除模板失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// PreviewEmailTemplate 使用示例数据渲染模板
+func PreviewEmailTemplate(c *gin.Context) {
+	var req EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	if req.Lang == "" {
+		req.Lang = email.DefaultLanguage
+	}
+	if !validTemplate(req.Name, req.Lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的模板名称或语言"})
+		return
+	}
+
+	t := &email.Template{Name: req.Name, Lang: req.Lang, Subject: req.Subject, Text: req.Text, HTML: req.HTML}
+	if req.Subject == "" && req.Text == "" && req.HTML == "" {
+		var err error
+		if t, err = email.LoadTemplate(c.MustGet("db").(*gorm.DB), req.Name, req.Lang); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	data := email.SampleData(req.Name)
+	for k, v := range req.Data {
+		data[k] = v
+	}
+	msg, err := t.Render(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板渲染失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, msg)
+}
+
+func validTemplate(name, lang string) bool {
+	return containsString(email.TemplateNames, name) && containsString(email.Languages, lang)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
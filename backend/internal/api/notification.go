@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// GetNotifications 分页查询通知发送记录，每次发送、重试、手动重发和测试各一条
+// 查询参数：domain_id、server_id（同时匹配服务器登记的地址）、ip、alert_id、backup_log_id、outbox_message_id、kind、channel、status、
+// from/to（发送时间）、page、page_size
+func GetNotifications(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.NotificationAttempt{})
+
+	if serverID := c.Query("server_id"); serverID != "" {
+		addresses := db.Model(&model.ServerAddress{}).Select("ip").Where("server_id = ?", serverID)
+		query = query.Where("server_id = ? OR ip IN (?)", serverID, addresses)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		normalized, err := model.NormalizeIP(ip)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+			return
+		}
+		query = query.Where("ip = ?", normalized)
+	}
+	for _, field := range []string{"domain_id", "alert_id", "backup_log_id", "outbox_message_id", "kind", "channel", "status"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := model.ParseBackupTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的from参数"})
+			return
+		}
+		query = query.Where("started_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := model.ParseBackupTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的to参数"})
+			return
+		}
+		query = query.Where("started_at <= ?", to)
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的page参数"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultBackupLogPageSize)))
+	if err != nil || pageSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的page_size参数"})
+		return
+	}
+	if pageSize > maxBackupLogPageSize {
+		pageSize = maxBackupLogPageSize
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取发送记录失败"})
+		return
+	}
+	attempts := []model.NotificationAttempt{}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取发送记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     attempts,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
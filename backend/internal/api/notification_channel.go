@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/notify"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// NotificationChannel 通知渠道信息，不包含地址和密钥
+type NotificationChannel struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// GetNotificationChannels 获取已配置的通知渠道
+func GetNotificationChannels(c *gin.Context) {
+	channels := []NotificationChannel{}
+	for _, n := range notify.Channels() {
+		channels = append(channels, NotificationChannel{Name: n.Name(), Type: n.Type()})
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+// TestNotificationChannel 向指定渠道发送一条测试通知，不经过发件箱，发送结果写入发送记录。
+// PagerDuty、Opsgenie 渠道可指定 dedupKey 和 action（trigger、acknowledge、resolve）测试事件的创建和关闭
+func TestNotificationChannel(c *gin.Context) {
+	n, ok := notify.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知渠道不存在"})
+		return
+	}
+
+	var req struct {
+		To       []string `json:"to"`
+		Severity string   `json:"severity"`
+		DedupKey string   `json:"dedupKey"`
+		Action   string   `json:"action"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	db := c.MustGet("db").(*gorm.DB)
+	response, err := outbox.SendTest(db, n, notify.Notification{
+		Kind:     "test",
+		To:       req.To,
+		Subject:  "测试通知",
+		Body:     "这是一条来自证书与备份监控系统的测试通知，发送时间 " + time.Now().Format("2006-01-02 15:04:05"),
+		Severity: req.Severity,
+		DedupKey: req.DedupKey,
+		Action:   req.Action,
+	}, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "发送失败: " + err.Error(), "response": response})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "发送成功", "response": response})
+}
+
+// RouteDryRunRequest 路由试算的事件，domainId、serverId 或 ip 指定告警对象时使用其标签、环境和负责团队，
+// 请求中的 tags、environment、ownerTeam 覆盖对象的属性
+type RouteDryRunRequest struct {
+	Kind        string    `json:"kind" binding:"required"`
+	Severity    string    `json:"severity"`
+	DomainID    uint      `json:"domainId"`
+	ServerID    uint      `json:"serverId"`
+	Ip          string    `json:"ip"`
+	Tags        []string  `json:"tags"`
+	Environment string    `json:"environment"`
+	OwnerTeam   string    `json:"ownerTeam"`
+	To          []string  `json:"to"`   // 事件自带的收件人，指定域名时默认为域名的通知邮箱
+	Time        time.Time `json:"time"` // 事件时间，用于判断安静时段，默认当前时间
+}
+
+// GetNotificationRoutes 获取通知路由规则和人员组
+func GetNotificationRoutes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"rules":           notify.Rules(),
+		"recipientGroups": notify.RecipientGroups(),
+	})
+}
+
+// DryRunNotificationRoute 计算一个事件会通知到哪些渠道和收件人，不发送
+func DryRunNotificationRoute(c *gin.Context) {
+	var req RouteDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	target := alert.Target{DomainID: req.DomainID, ServerID: req.ServerID}
+	if req.Ip != "" {
+		ip, err := model.NormalizeIP(req.Ip)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+			return
+		}
+		target.Ip = ip
+	}
+	event := notify.Event{Kind: req.Kind, Severity: req.Severity, Labels: alert.LabelsFor(db, target), To: req.To}
+	if req.Tags != nil {
+		event.Tags = req.Tags
+	}
+	if req.Environment != "" {
+		event.Environment = req.Environment
+	}
+	if req.OwnerTeam != "" {
+		event.OwnerTeam = req.OwnerTeam
+	}
+	if len(event.To) == 0 && req.DomainID != 0 {
+		var domain model.Domain
+		if err := db.First(&domain, req.DomainID).Error; err == nil {
+			event.To = domainRecipients(&domain)
+		}
+	}
+
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"event": event,
+		"plan":  notify.Route(event, now),
+	})
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/oncall"
+	"gorm.io/gorm"
+)
+
+// OnCallRotationRequest 值班轮换的请求数据
+type OnCallRotationRequest struct {
+	Name     string    `json:"name" binding:"required"`
+	Members  []string  `json:"members" binding:"required"`  // 值班成员邮箱，按值班顺序
+	Handover string    `json:"handover" binding:"required"` // daily 或 weekly
+	StartsAt time.Time `json:"startsAt" binding:"required"` // 第一位成员开始值班的时间，之后在同一时刻交接
+	Timezone string    `json:"timezone"`
+}
+
+// OnCallOverrideRequest 替班的请求数据
+type OnCallOverrideRequest struct {
+	Email    string    `json:"email" binding:"required"`
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// OnCallRotationResponse 值班轮换及当前值班人
+type OnCallRotationResponse struct {
+	model.OnCallRotation
+	Members []string       `json:"members"`
+	OnCall  *oncall.Shift  `json:"onCall"`           // 当前值班，轮换尚未开始时为空
+	Shifts  []oncall.Shift `json:"shifts,omitempty"` // 未来的值班安排，仅获取单个轮换时返回
+}
+
+// GetOnCallRotations 获取所有值班轮换及当前值班人
+func GetOnCallRotations(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var rotations []model.OnCallRotation
+	if err := db.Order("name ASC").Find(&rotations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取值班轮换失败"})
+		return
+	}
+	now := time.Now()
+	list := make([]OnCallRotationResponse, 0, len(rotations))
+	for i := range rotations {
+		list = append(list, onCallRotationResponse(db, &rotations[i], now))
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetOnCallRotation 获取值班轮换、未结束的替班以及接下来 days 天（默认 14，最多 90）的值班安排
+func GetOnCallRotation(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	now := time.Now()
+	var rotation model.OnCallRotation
+	err := db.Preload("Overrides", func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("ends_at > ?", now).Order("starts_at ASC")
+	}).First(&rotation, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "值班轮换不存在"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days <= 0 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days 参数必须为 1 到 90 之间的整数"})
+		return
+	}
+	resp := onCallRotationResponse(db, &rotation, now)
+	if resp.Shifts, err = oncall.Schedule(db, &rotation, now, now.AddDate(0, 0, days)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算值班安排失败"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddOnCallRotation 添加值班轮换
+func AddOnCallRotation(c *gin.Context) {
+	var req OnCallRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var rotation model.OnCallRotation
+	if msg := applyOnCallRotationRequest(db, &rotation, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := db.Create(&rotation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加值班轮换失败"})
+		return
+	}
+	c.JSON(http.StatusOK, onCallRotationResponse(db, &rotation, time.Now()))
+}
+
+// UpdateOnCallRotation 更新值班轮换
+func UpdateOnCallRotation(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var rotation model.OnCallRotation
+	if err := db.First(&rotation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "值班轮换不存在"})
+		return
+	}
+
+	var req OnCallRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	if msg := applyOnCallRotationRequest(db, &rotation, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := db.Save(&rotation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新值班轮换失败"})
+		return
+	}
+	c.JSON(http.StatusOK, onCallRotationResponse(db, &rotation, time.Now()))
+}
+
+// DeleteOnCallRotation 删除值班轮换及其替班，引用它的路由规则改为发送给默认收件人
+func DeleteOnCallRotation(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rotation_id = ?", c.Param("id")).Delete(&model.OnCallOverride{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.OnCallRotation{}, c.Param("id")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除值班轮换失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// AddOnCallOverride 为值班轮换添加替班，与已有替班重叠时以新添加的为准
+func AddOnCallOverride(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var rotation model.OnCallRotation
+	if err := db.First(&rotation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "值班轮换不存在"})
+		return
+	}
+
+	var req OnCallOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	addr, err := normalizeRecipients(req.Email)
+	if err != nil || addr == "" || strings.Contains(addr, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的替班邮箱"})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "结束时间必须晚于开始时间"})
+		return
+	}
+
+	override := model.OnCallOverride{
+		RotationID: rotation.ID,
+		Email:      addr,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Reason:     req.Reason,
+		CreatedBy:  c.GetString("username"),
+	}
+	if err := db.Create(&override).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加替班失败"})
+		return
+	}
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteOnCallOverride 删除替班
+func DeleteOnCallOverride(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	result := db.Where("id = ? AND rotation_id = ?", c.Param("overrideId"), c.Param("id")).Delete(&model.OnCallOverride{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除替班失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "替班不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// applyOnCallRotationRequest 校验请求并写入值班轮换，返回错误信息
+func applyOnCallRotationRequest(db *gorm.DB, rotation *model.OnCallRotation, req *OnCallRotationRequest) string {
+	name := strings.TrimSpace(req.Name)
+	var count int64
+	db.Model(&model.OnCallRotation{}).Where("name = ? AND id <> ?", name, rotation.ID).Count(&count)
+	if count > 0 {
+		return "值班轮换名称已存在"
+	}
+	members, err := normalizeRecipients(strings.Join(req.Members, ","))
+	if err != nil {
+		return "无效的成员邮箱: " + err.Error()
+	}
+
+	rotation.Name = name
+	rotation.Members = members
+	rotation.Handover = req.Handover
+	rotation.StartsAt = req.StartsAt
+	rotation.Timezone = req.Timezone
+	if err := oncall.Validate(rotation); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func onCallRotationResponse(db *gorm.DB, rotation *model.OnCallRotation, now time.Time) OnCallRotationResponse {
+	resp := OnCallRotationResponse{OnCallRotation: *rotation, Members: oncall.Members(rotation)}
+	if resp.Members == nil {
+		resp.Members = []string{}
+	}
+	if shift, err := oncall.OnCall(db, rotation, now); err == nil && shift.Email != "" {
+		resp.OnCall = &shift
+	}
+	return resp
+}
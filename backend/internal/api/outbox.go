@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// GetOutboxMessages 获取发件箱中的通知，可按 status、kind 过滤
+func GetOutboxMessages(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.OutboxMessage{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var messages []model.OutboxMessage
+	if err := query.Order("id DESC").Limit(500).Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取发件箱失败"})
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// ResendOutboxMessage 手动重新发送发件箱中的通知
+func ResendOutboxMessage(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var msg model.OutboxMessage
+	if err := db.First(&msg, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "消息不存在"})
+		return
+	}
+	if msg.Status == model.OutboxSent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "消息已发送"})
+		return
+	}
+
+	if err := outbox.Resend(db, &msg, c.GetString("username")); errors.Is(err, outbox.ErrInFlight) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "发送失败: " + err.Error(), "message": msg})
+		return
+	}
+	c.JSON(http.StatusOK, msg)
+}
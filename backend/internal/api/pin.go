@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/pkg/ssl"
+	"gorm.io/gorm"
+)
+
+type AddPinRequest struct {
+	Pin   string `json:"pin" binding:"required"`
+	Label string `json:"label"`
+}
+
+// GetDomainPins 获取域名的 SPKI pin 列表
+func GetDomainPins(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	id := c.Param("id")
+	var pins []model.DomainPin
+	if err := db.Where("domain_id = ?", id).Order("id ASC").Find(&pins).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取pin列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, pins)
+}
+
+// AddDomainPin 为域名添加期望的 SPKI pin
+func AddDomainPin(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var domain model.Domain
+	if err := db.First(&domain, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "域名不存在"})
+		return
+	}
+
+	var req AddPinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	pinValue, err := ssl.NormalizePin(req.Pin)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing model.DomainPin
+	if err := db.Where("domain_id = ? AND pin = ?", domain.ID, pinValue).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pin已存在"})
+		return
+	}
+
+	pin := model.DomainPin{DomainID: domain.ID, Pin: pinValue, Label: req.Label}
+	if err := db.Create(&pin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加pin失败"})
+		return
+	}
+	c.JSON(http.StatusOK, pin)
+}
+
+// DeleteDomainPin 删除域名的 SPKI pin
+func DeleteDomainPin(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	result := db.Where("id = ? AND domain_id = ?", c.Param("pinId"), c.Param("id")).Delete(&model.DomainPin{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除pin失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pin不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// PreviewDomainPins 连接域名获取当前证书链，预览链中各证书的 pin 以及已配置 pin 的满足情况
+func PreviewDomainPins(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var domain model.Domain
+	if err := db.First(&domain, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "域名不存在"})
+		return
+	}
+
+	certInfo, err := ssl.CheckCertificate(domain.DomainName)
+	if err != nil || len(certInfo.Chain) == 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "获取证书链失败"})
+		return
+	}
+
+	var pins []model.DomainPin
+	if err := db.Where("domain_id = ?", domain.ID).Find(&pins).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取pin列表失败"})
+		return
+	}
+	satisfied := matchPins(pins, certInfo.Chain)
+
+	c.JSON(http.StatusOK, gin.H{
+		"chain":     certInfo.Chain,
+		"satisfied": satisfied,
+		"ok":        len(pins) == 0 || len(satisfied) > 0,
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// RestoreTestRequest 恢复验证脚本上报的结果
+type RestoreTestRequest struct {
+	BackupLogID            uint             `json:"backup_log_id" binding:"required"`
+	VerifiedBy             string           `json:"verified_by"`
+	Target                 string           `json:"target"`
+	Checksum               string           `json:"checksum"`
+	ChecksumMatch          *bool            `json:"checksum_match"` // 未提供时根据 checksum 与备份日志的校验和比较
+	RestoreDurationSeconds int64            `json:"restore_duration_seconds"`
+	Result                 int              `json:"result"` // 0:成功,其他:失败
+	Message                string           `json:"message"`
+	TestedAt               model.BackupTime `json:"tested_at"` // 未提供时为接收时间
+}
+
+// CreateRestoreTest 记录一次恢复验证结果
+func CreateRestoreTest(c *gin.Context) {
+	var req RestoreTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	var backupLog model.BackupLog
+	if err := db.First(&backupLog, req.BackupLogID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到指定的备份日志"})
+		return
+	}
+	if token := agentTokenFromContext(c); token != nil && backupLog.Ip != token.Ip {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权记录其他服务器的恢复验证"})
+		return
+	}
+
+	test := model.RestoreTest{
+		BackupLogID:            backupLog.Id,
+		Ip:                     backupLog.Ip,
+		ServerID:               backupLog.ServerID,
+		VerifiedBy:             req.VerifiedBy,
+		Target:                 req.Target,
+		Checksum:               strings.TrimSpace(req.Checksum),
+		ChecksumMatch:          req.ChecksumMatch,
+		RestoreDurationSeconds: req.RestoreDurationSeconds,
+		Result:                 req.Result,
+		Message:                req.Message,
+		TestedAt:               req.TestedAt.Time,
+	}
+	if test.TestedAt.IsZero() {
+		test.TestedAt = time.Now()
+	}
+	if test.ChecksumMatch == nil && test.Checksum != "" && backupLog.Checksum != "" {
+		match := strings.EqualFold(test.Checksum, backupLog.Checksum)
+		test.ChecksumMatch = &match
+	}
+	// 校验和不一致的恢复不算成功
+	if test.ChecksumMatch != nil && !*test.ChecksumMatch && test.Result == 0 {
+		test.Result = 1
+	}
+
+	if err := db.Create(&test).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "记录恢复验证失败"})
+		return
+	}
+	c.JSON(http.StatusOK, test)
+}
+
+// GetRestoreTests 查询恢复验证记录，支持 ip、server_id、backup_log_id、result 参数
+func GetRestoreTests(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	query := db.Model(&model.RestoreTest{})
+
+	if ip := c.Query("ip"); ip != "" {
+		normalized, err := model.NormalizeIP(ip)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ip参数"})
+			return
+		}
+		query = query.Where("ip = ?", normalized)
+	}
+	for _, field := range []string{"server_id", "backup_log_id", "result"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+
+	tests := []model.RestoreTest{}
+	if err := query.Order("tested_at DESC, id DESC").Limit(500).Find(&tests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取恢复验证记录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
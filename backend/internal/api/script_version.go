@@ -0,0 +1,212 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// ScriptVersionUsage 某个脚本版本的使用情况
+type ScriptVersionUsage struct {
+	Version string   `json:"version"`
+	Servers int      `json:"servers"` // 登记的服务器按服务器计数，未登记的地址按地址计数
+	Ips     []string `json:"ips"`     // 各服务器最近一次上报使用的地址
+	Latest  bool     `json:"latest"`
+}
+
+// OutdatedServer 运行旧版本脚本的服务器
+type OutdatedServer struct {
+	ServerID   uint   `json:"serverId,omitempty"` // 未登记的地址为 0
+	Ip         string `json:"ip"`
+	ServerName string `json:"serverName"`
+	Version    string `json:"version"`
+	Expected   string `json:"expected"`
+	Reason     string `json:"reason"`
+}
+
+// compareVersions 比较点分数字版本号，如 "1.0.0.1"，返回 -1、0 或 1；非数字部分按字符串比较
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(strings.TrimSpace(a), "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(strings.TrimSpace(b), "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		if sa == "" {
+			na, errA = 0, nil
+		}
+		if sb == "" {
+			nb, errB = 0, nil
+		}
+		if errA == nil && errB == nil {
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if sa != sb {
+			if sa < sb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// latestScriptVersion 返回最新的脚本版本：优先使用配置，否则取所有上报中的最高版本
+func latestScriptVersion(db *gorm.DB) string {
+	if v := config.AppConfig.Backup.LatestScriptVersion; v != "" {
+		return v
+	}
+	var versions []string
+	db.Model(&model.BackupLog{}).Distinct("script_version").Pluck("script_version", &versions)
+	latest := ""
+	for _, v := range versions {
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// checkScriptDowngrade 服务器上报的脚本版本低于上一次时发送告警，升级到最新版本后告警自动解决
+func checkScriptDowngrade(db *gorm.DB, backupLog *model.BackupLog) {
+	var previous model.BackupLog
+	if err := db.Where("ip = ? AND id < ?", backupLog.Ip, backupLog.Id).Order("id DESC").First(&previous).Error; err != nil {
+		return
+	}
+	if compareVersions(backupLog.ScriptVersion, previous.ScriptVersion) >= 0 {
+		if a, err := alert.Active(db, alert.ScriptDowngradeKey(backupLog.Ip)); err == nil &&
+			compareVersions(backupLog.ScriptVersion, latestScriptVersion(db)) >= 0 {
+			alert.SetResolved(db, a, "", "", time.Now())
+		}
+		return
+	}
+
+	message := fmt.Sprintf("备份脚本版本降级: %s -> %s", previous.ScriptVersion, backupLog.ScriptVersion)
+	target := alert.Target{ServerID: backupLog.ServerID, Ip: backupLog.Ip}
+	a, notify := alert.Raise(db, alert.Condition{
+		Key:      alert.ScriptDowngradeKey(backupLog.Ip),
+		Kind:     "script_downgrade",
+		Severity: model.SeverityWarning,
+		Message:  message,
+		Target:   target,
+	}, time.Now())
+	if !notify {
+		return
+	}
+	msg := email.BackupAlertMessage(db, backupLog.Ip, backupLog.ServerName, message)
+	m := alert.Message(db, a)
+	m.Subject, m.Body, m.HTML = msg.Subject, msg.Text, msg.HTML
+	_, err := outbox.Deliver(db, m)
+	if err != nil {
+		log.Printf("Failed to send script downgrade alert for %s, queued for retry: %v", backupLog.Ip, err)
+	}
+}
+
+// GetScriptVersions 按脚本版本统计服务器数量，并列出运行旧版本的服务器
+func GetScriptVersions(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	// 每个地址最近一次上报的记录
+	var latestByIp []model.BackupLog
+	latestIds := db.Model(&model.BackupLog{}).Select("MAX(id)").Group("ip")
+	if err := db.Where("id IN (?)", latestIds).Order("ip ASC").Find(&latestByIp).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份日志失败"})
+		return
+	}
+
+	// 按登记的服务器合并多个地址，每台服务器只统计最近一次上报；未登记的地址各自算作一台服务器
+	var addresses []model.ServerAddress
+	if err := db.Find(&addresses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取服务器地址失败"})
+		return
+	}
+	serverOf := make(map[string]uint, len(addresses))
+	for _, a := range addresses {
+		serverOf[a.Ip] = a.ServerID
+	}
+	logs := []model.BackupLog{}
+	serverLog := make(map[uint]int)
+	for _, l := range latestByIp {
+		serverID, ok := serverOf[l.Ip]
+		if !ok {
+			l.ServerID = 0
+			logs = append(logs, l)
+			continue
+		}
+		l.ServerID = serverID
+		if i, seen := serverLog[serverID]; seen {
+			if l.Id > logs[i].Id {
+				logs[i] = l
+			}
+			continue
+		}
+		serverLog[serverID] = len(logs)
+		logs = append(logs, l)
+	}
+
+	expected := make(map[uint]string)
+	var servers []model.Server
+	db.Where("expected_script_version <> ''").Find(&servers)
+	for _, s := range servers {
+		expected[s.ID] = s.ExpectedScriptVersion
+	}
+
+	latest := latestScriptVersion(db)
+	usage := make(map[string]*ScriptVersionUsage)
+	outdated := []OutdatedServer{}
+	for _, l := range logs {
+		u, ok := usage[l.ScriptVersion]
+		if !ok {
+			u = &ScriptVersionUsage{Version: l.ScriptVersion, Latest: l.ScriptVersion == latest}
+			usage[l.ScriptVersion] = u
+		}
+		u.Servers++
+		u.Ips = append(u.Ips, l.Ip)
+
+		if want, ok := expected[l.ServerID]; ok && compareVersions(l.ScriptVersion, want) != 0 {
+			outdated = append(outdated, OutdatedServer{ServerID: l.ServerID, Ip: l.Ip, ServerName: l.ServerName,
+				Version: l.ScriptVersion, Expected: want, Reason: "与服务器登记的期望版本不一致"})
+		} else if compareVersions(l.ScriptVersion, latest) < 0 {
+			outdated = append(outdated, OutdatedServer{ServerID: l.ServerID, Ip: l.Ip, ServerName: l.ServerName,
+				Version: l.ScriptVersion, Expected: latest, Reason: "低于最新版本"})
+		}
+	}
+
+	versions := make([]*ScriptVersionUsage, 0, len(usage))
+	for _, u := range usage {
+		versions = append(versions, u)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"latest":   latest,
+		"versions": versions,
+		"outdated": outdated,
+	})
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+type ServerRequest struct {
+	Hostname              string   `json:"hostname" binding:"required"`
+	Owner                 string   `json:"owner"`
+	Environment           string   `json:"environment"`
+	Tags                  string   `json:"tags"`
+	ExpectedScriptVersion string   `json:"expectedScriptVersion"`
+	Ips                   []string `json:"ips"`
+}
+
+// BackupStreak 当前连续相同结果的备份次数
+type BackupStreak struct {
+	Status string `json:"status"` // success 或 failure
+	Count  int    `json:"count"`
+}
+
+// ServerSummary 单台服务器的备份概况
+type ServerSummary struct {
+	Server             model.Server             `json:"server"`
+	LastRun            *model.BackupLog         `json:"lastRun"`
+	LastSuccess        *model.BackupLog         `json:"lastSuccess"`
+	Streak             BackupStreak             `json:"streak"`
+	SuccessRate30d     float64                  `json:"successRate30d"`
+	Runs30d            int64                    `json:"runs30d"`
+	AlertStates        []model.BackupAlertState `json:"alertStates"`        // 仍处于告警中的地址
+	OutstandingNotices []model.OutboxMessage    `json:"outstandingNotices"` // 尚未成功发送的通知
+}
+
+// GetServers 获取服务器列表
+func GetServers(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var servers []model.Server
+	if err := db.Preload("Addresses").Order("hostname ASC").Find(&servers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取服务器列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, servers)
+}
+
+// AddServer 登记服务器，并将已有的同地址备份日志关联到该服务器
+func AddServer(c *gin.Context) {
+	var req ServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+	server := model.Server{}
+	applyServerRequest(&server, &req)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&server).Error; err != nil {
+			return err
+		}
+		return setServerAddresses(tx, &server, req.Ips)
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "添加服务器失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, server)
+}
+
+// UpdateServer 更新服务器信息及地址
+func UpdateServer(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var server model.Server
+	if err := db.First(&server, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "服务器不存在"})
+		return
+	}
+
+	var req ServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	applyServerRequest(&server, &req)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&server).Error; err != nil {
+			return err
+		}
+		return setServerAddresses(tx, &server, req.Ips)
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新服务器失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, server)
+}
+
+// DeleteServer 删除服务器，备份日志保留但不再关联
+func DeleteServer(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	id := c.Param("id")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.BackupLog{}).Where("server_id = ?", id).Update("server_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("server_id = ?", id).Delete(&model.ServerAddress{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Server{}, id).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除服务器失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetServerSummary 获取服务器的备份概况：最近一次运行、最近一次成功、当前连续结果、30天成功率及未解决的告警
+func GetServerSummary(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	var server model.Server
+	if err := db.Preload("Addresses").First(&server, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "服务器不存在"})
+		return
+	}
+
+	summary := ServerSummary{
+		Server:             server,
+		AlertStates:        []model.BackupAlertState{},
+		OutstandingNotices: []model.OutboxMessage{},
+	}
+	logs := db.Model(&model.BackupLog{}).Where("server_id = ?", server.ID)
+
+	var lastRun model.BackupLog
+	if err := logs.Session(&gorm.Session{}).Order("id DESC").First(&lastRun).Error; err == nil {
+		summary.LastRun = &lastRun
+	}
+	var lastSuccess model.BackupLog
+	if err := logs.Session(&gorm.Session{}).Where("end_time IS NOT NULL AND backup_status = 0").
+		Order("id DESC").First(&lastSuccess).Error; err == nil {
+		summary.LastSuccess = &lastSuccess
+	}
+
+	// 按最近结束的备份计算连续结果
+	var recent []model.BackupLog
+	logs.Session(&gorm.Session{}).Where("end_time IS NOT NULL").Order("id DESC").Limit(500).Find(&recent)
+	for i, l := range recent {
+		status := "success"
+		if l.BackupStatus != 0 {
+			status = "failure"
+		}
+		if i == 0 {
+			summary.Streak.Status = status
+		}
+		if status != summary.Streak.Status {
+			break
+		}
+		summary.Streak.Count++
+	}
+
+	since := time.Now().AddDate(0, 0, -30)
+	var finished, succeeded int64
+	logs.Session(&gorm.Session{}).Where("start_time >= ?", since).Count(&summary.Runs30d)
+	logs.Session(&gorm.Session{}).Where("start_time >= ? AND end_time IS NOT NULL", since).Count(&finished)
+	logs.Session(&gorm.Session{}).Where("start_time >= ? AND end_time IS NOT NULL AND backup_status = 0", since).Count(&succeeded)
+	if finished > 0 {
+		summary.SuccessRate30d = float64(succeeded) / float64(finished)
+	}
+
+	var ips []string
+	for _, a := range server.Addresses {
+		ips = append(ips, a.Ip)
+	}
+	if len(ips) > 0 {
+		db.Where("ip IN ? AND level > ?", ips, model.AlertLevelNone).Find(&summary.AlertStates)
+	}
+	db.Where("status <> ? AND backup_log_id IN (?)", model.OutboxSent,
+		db.Model(&model.BackupLog{}).Select("id").Where("server_id = ?", server.ID)).
+		Order("id DESC").Find(&summary.OutstandingNotices)
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func applyServerRequest(server *model.Server, req *ServerRequest) {
+	server.Hostname = req.Hostname
+	server.Owner = req.Owner
+	server.Environment = req.Environment
+	server.Tags = req.Tags
+	server.ExpectedScriptVersion = req.ExpectedScriptVersion
+}
+
+// setServerAddresses 替换服务器的地址列表，并关联这些地址的备份日志
+func setServerAddresses(tx *gorm.DB, server *model.Server, ips []string) error {
+	if err := tx.Where("server_id = ?", server.ID).Delete(&model.ServerAddress{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.BackupLog{}).Where("server_id = ?", server.ID).Update("server_id", 0).Error; err != nil {
+		return err
+	}
+
+	server.Addresses = nil
+	for _, ip := range ips {
+		if strings.TrimSpace(ip) == "" {
+			continue
+		}
+		ip, err := model.NormalizeIP(ip)
+		if err != nil {
+			return err
+		}
+		addr := model.ServerAddress{ServerID: server.ID, Ip: ip}
+		if err := tx.Create(&addr).Error; err != nil {
+			return err
+		}
+		server.Addresses = append(server.Addresses, addr)
+		if err := tx.Model(&model.BackupLog{}).Where("ip = ?", ip).Update("server_id", server.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverIDForIP 根据规范化后的地址查找登记的服务器，未登记时返回 0
+func serverIDForIP(db *gorm.DB, ip string) uint {
+	var addr model.ServerAddress
+	if err := db.Where("ip = ?", ip).First(&addr).Error; err != nil {
+		return 0
+	}
+	return addr.ServerID
+}
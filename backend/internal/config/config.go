@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,8 +12,9 @@ import (
 // Config 应用配置结构体
 type Config struct {
 	Server struct {
-		Host string `yaml:"host"`
-		Port int    `yaml:"port"`
+		Host           string   `yaml:"host"`
+		Port           int      `yaml:"port"`
+		TrustedProxies []string `yaml:"trusted_proxies"` // 可信反向代理的地址或网段，只有来自这些地址的 X-Forwarded-For 才被采信，默认不信任任何代理
 	} `yaml:"server"`
 
 	MySQL struct {
@@ -23,6 +26,127 @@ type Config struct {
 	} `yaml:"mysql"`
 
 	Email EmailConfig `yaml:"email"`
+
+	SSL SSLConfig `yaml:"ssl"`
+
+	Backup BackupConfig `yaml:"backup"`
+
+	Outbox OutboxConfig `yaml:"outbox"`
+
+	Notifications NotificationConfig `yaml:"notifications"`
+
+	Digests []DigestConfig `yaml:"digests"`
+}
+
+// DigestConfig 定期发送的汇总报告
+type DigestConfig struct {
+	Name       string   `yaml:"name"`        // 报告名称，如 daily、weekly
+	Schedule   string   `yaml:"schedule"`    // 标准 cron 表达式，如 "0 8 * * *"（每天 8 点）、"0 8 * * 1"（每周一 8 点）
+	Timezone   string   `yaml:"timezone"`    // 计划和报告使用的时区，如 Asia/Shanghai，默认服务器本地时区
+	Recipients []string `yaml:"recipients"`  // 收件人，为空时使用默认收件人
+	ExpiryDays int      `yaml:"expiry_days"` // 列出多少天内过期的证书，默认 30
+	PeriodDays int      `yaml:"period_days"` // 统计最近多少天的备份成功率，默认 7
+}
+
+// Location 返回报告使用的时区
+func (d DigestConfig) Location() (*time.Location, error) {
+	if d.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(d.Timezone)
+}
+
+// ExpiryWindow 返回列出即将过期证书的天数
+func (d DigestConfig) ExpiryWindow() int {
+	if d.ExpiryDays <= 0 {
+		return 30
+	}
+	return d.ExpiryDays
+}
+
+// Period 返回统计备份成功率的天数
+func (d DigestConfig) Period() int {
+	if d.PeriodDays <= 0 {
+		return 7
+	}
+	return d.PeriodDays
+}
+
+// NotificationConfig 通知渠道及路由配置，email 渠道始终可用
+type NotificationConfig struct {
+	Channels        []ChannelConfig     `yaml:"channels"`
+	Routes          []NotificationRoute `yaml:"routes"`
+	DefaultChannels []string            `yaml:"default_channels"` // 没有匹配路由时使用的渠道，默认 ["email"]
+
+	RecipientGroups []RecipientGroup `yaml:"recipient_groups"`
+	Rules           []RoutingRule    `yaml:"rules"` // 按告警对象的标签、环境、负责团队和级别路由，有匹配规则时不再使用 routes
+}
+
+// RecipientGroup 一组接收通知的人员及其渠道
+type RecipientGroup struct {
+	Name       string      `yaml:"name" json:"name"`
+	Emails     []string    `yaml:"emails" json:"emails"`
+	Channels   []string    `yaml:"channels" json:"channels"` // 额外发送的渠道，如该团队的聊天机器人
+	QuietHours *QuietHours `yaml:"quiet_hours" json:"quietHours"`
+}
+
+// QuietHours 安静时段，期间低于 min_severity 的通知推迟到时段结束后发送
+type QuietHours struct {
+	Start       string `yaml:"start" json:"start"`              // 开始时间，如 "22:00"
+	End         string `yaml:"end" json:"end"`                  // 结束时间，如 "07:00"，早于开始时间表示跨午夜
+	Timezone    string `yaml:"timezone" json:"timezone"`        // 默认服务器本地时区
+	MinSeverity string `yaml:"min_severity" json:"minSeverity"` // 安静时段内仍立即发送的最低级别，默认 critical
+}
+
+// RoutingRule 通知路由规则，各匹配条件为空时不限制，tags 与告警对象的标签有交集即匹配
+type RoutingRule struct {
+	Name         string   `yaml:"name" json:"name"`
+	Kinds        []string `yaml:"kinds" json:"kinds"`
+	Tags         []string `yaml:"tags" json:"tags"`
+	Environments []string `yaml:"environments" json:"environments"`
+	OwnerTeams   []string `yaml:"owner_teams" json:"ownerTeams"`
+	Severities   []string `yaml:"severities" json:"severities"` // info、warning、critical
+	Groups       []string `yaml:"groups" json:"groups"`         // 接收通知的人员组
+	Channels     []string `yaml:"channels" json:"channels"`     // 额外发送的渠道，email 渠道发送给事件自带的收件人或默认收件人
+	Rotations    []string `yaml:"rotations" json:"rotations"`   // 值班轮换，以邮件发送给当前值班人，见 /api/onCallRotations
+}
+
+// ChannelConfig 通知渠道配置
+type ChannelConfig struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`        // email、webhook、slack、teams、dingtalk、wecom、feishu、telegram、pagerduty、opsgenie
+	URL        string `yaml:"url"`         // webhook / 机器人地址；telegram、pagerduty、opsgenie 为 API 地址，默认使用官方地址
+	Secret     string `yaml:"secret"`      // webhook 的 HMAC 签名密钥，钉钉、飞书机器人的加签密钥
+	BotToken   string `yaml:"bot_token"`   // telegram 机器人 token
+	ChatID     string `yaml:"chat_id"`     // telegram 会话 ID
+	RoutingKey string `yaml:"routing_key"` // pagerduty Events API v2 的 integration key
+	APIKey     string `yaml:"api_key"`     // opsgenie 的 API key
+	Timeout    int    `yaml:"timeout"`     // 请求超时（秒），默认 10
+}
+
+// NotificationRoute 按通知类型选择渠道，kinds 为空时匹配所有类型
+type NotificationRoute struct {
+	Kinds    []string `yaml:"kinds"`
+	Channels []string `yaml:"channels"`
+}
+
+// OutboxConfig 通知发件箱配置结构体
+type OutboxConfig struct {
+	Interval    int `yaml:"interval"`     // 重试任务执行间隔（秒），默认 30
+	MaxAttempts int `yaml:"max_attempts"` // 最大发送次数，超过后进入死信状态，默认 8
+}
+
+// IntervalDuration 返回重试任务执行间隔
+func (o OutboxConfig) IntervalDuration() time.Duration {
+	if o.Interval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(o.Interval) * time.Second
+}
+
+// SSLConfig 证书检查配置结构体
+type SSLConfig struct {
+	DNSSECResolver string `yaml:"dnssec_resolver"` // 用于 TLSA 查询的 DNSSEC 验证解析器，如 "1.1.1.1:53"
 }
 
 // EmailConfig 邮件配置结构体
@@ -34,6 +158,90 @@ type EmailConfig struct {
 	FromAddress string   `yaml:"from_address"`
 	ToAddresses []string `yaml:"to_addresses"`
 	Enabled     bool     `yaml:"enabled"`
+	Language    string   `yaml:"language"`     // 邮件模板语言：zh 或 en，默认 zh
+	TemplateDir string   `yaml:"template_dir"` // 模板目录，文件名为 <名称>.<语言>.subject|txt|html.tmpl，优先于内置模板
+
+	FromName           string `yaml:"from_name"`            // 发件人显示名称
+	TLSMode            string `yaml:"tls_mode"`             // tls（隐式 TLS）、starttls（必须）、opportunistic（默认）、none；465 端口默认 tls
+	Auth               string `yaml:"auth"`                 // 认证方式：none、plain、login、cram-md5，为空时自动选择
+	CAFile             string `yaml:"ca_file"`              // 额外信任的 CA 证书（PEM）
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // 不校验服务器证书，仅用于测试
+	Timeout            int    `yaml:"timeout"`              // 连接及发送超时（秒），默认 30
+	HeloName           string `yaml:"helo_name"`            // EHLO 使用的主机名，默认 localhost
+}
+
+// BackupConfig 备份监控配置结构体
+type BackupConfig struct {
+	WatchdogInterval int      `yaml:"watchdog_interval"`  // 备份缺失检查间隔（秒），默认 60
+	LegacyRoutes     bool     `yaml:"legacy_routes"`      // 是否在根路径提供原 backup_api 的路由，供旧版备份脚本使用
+	LegacyAllowedIPs []string `yaml:"legacy_allowed_ips"` // 无需令牌即可访问原 backup_api 路由的地址或网段，供不发送认证头的旧版脚本使用
+
+	LatestScriptVersion string `yaml:"latest_script_version"` // 最新备份脚本版本，为空时取所有上报中的最高版本
+
+	AlertPolicy BackupAlertPolicy `yaml:"alert_policy"`
+	Retention   BackupRetention   `yaml:"retention"`
+
+	MaxOutputBytes int `yaml:"max_output_bytes"` // 保存的备份脚本输出上限（字节），超过时只保留末尾部分，默认 1MB
+
+	RestoreMaxAge int `yaml:"restore_max_age"` // 备份来源允许多少天没有成功的恢复验证，0 表示不检查
+}
+
+// MaxOutputBytesLimit 返回保存的备份脚本输出上限
+func (b BackupConfig) MaxOutputBytesLimit() int {
+	if b.MaxOutputBytes <= 0 {
+		return 1 << 20
+	}
+	return b.MaxOutputBytes
+}
+
+// BackupRetention 备份日志保留策略
+type BackupRetention struct {
+	RawDays    int    `yaml:"raw_days"`    // 原始备份日志保留天数，0 表示永久保留
+	RollupDays int    `yaml:"rollup_days"` // 每日汇总保留天数，0 表示永久保留
+	ArchiveDir string `yaml:"archive_dir"` // 删除前导出的归档目录（gzip 压缩的 JSON Lines），为空时不导出
+	Interval   int    `yaml:"interval"`    // 保留任务执行间隔（小时），默认 24
+}
+
+// IntervalDuration 返回保留任务执行间隔
+func (r BackupRetention) IntervalDuration() time.Duration {
+	if r.Interval <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(r.Interval) * time.Hour
+}
+
+// BackupAlertPolicy 备份失败告警策略
+type BackupAlertPolicy struct {
+	AlertAfter           int      `yaml:"alert_after"`           // 连续失败多少次后告警，默认 1
+	EscalateAfter        int      `yaml:"escalate_after"`        // 连续失败多少次后升级告警，0 表示不升级
+	EscalationRecipients []string `yaml:"escalation_recipients"` // 升级告警的额外收件人
+	DisableRecovery      bool     `yaml:"disable_recovery"`      // 是否关闭恢复通知
+}
+
+// AlertThreshold 返回告警所需的连续失败次数
+func (p BackupAlertPolicy) AlertThreshold() int {
+	if p.AlertAfter <= 0 {
+		return 1
+	}
+	return p.AlertAfter
+}
+
+// WatchdogIntervalDuration 返回备份缺失检查间隔
+func (b BackupConfig) WatchdogIntervalDuration() time.Duration {
+	if b.WatchdogInterval <= 0 {
+		return time.Minute
+	}
+	return time.Duration(b.WatchdogInterval) * time.Second
+}
+
+// MySQLDSN 根据 MySQL 配置生成数据库连接串
+func (c *Config) MySQLDSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.MySQL.User,
+		c.MySQL.Password,
+		c.MySQL.Host,
+		c.MySQL.Port,
+		c.MySQL.Database)
 }
 
 // AppConfig 全局配置变量
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/go-ssl-monitor/internal/model"
@@ -14,13 +13,7 @@ var DB *gorm.DB
 
 // InitDB 初始化数据库连接
 func InitDB() {
-	// 使用 AppConfig 中的配置
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		AppConfig.MySQL.User,
-		AppConfig.MySQL.Password,
-		AppConfig.MySQL.Host,
-		AppConfig.MySQL.Port,
-		AppConfig.MySQL.Database)
+	dsn := AppConfig.MySQLDSN()
 
 	var err error
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
@@ -32,11 +25,25 @@ func InitDB() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// 只对 domains 和 users 表进行自动迁移
-	err = DB.AutoMigrate(&model.Domain{}, &model.User{})
+	if err := prepareMigrations(DB); err != nil {
+		log.Fatalf("Failed to prepare database migration: %v", err)
+	}
+
+	// 自动迁移 domains、users、域名检查及备份相关表
+	err = DB.AutoMigrate(&model.Domain{}, &model.User{}, &model.DomainFinding{}, &model.HTTPCheck{}, &model.DomainPin{},
+		&model.BackupLog{}, &model.BackupSource{}, &model.AgentToken{}, &model.BackupAlertState{},
+		&model.OutboxMessage{}, &model.Server{}, &model.ServerAddress{},
+		&model.BackupDailyRollup{}, &model.BackupLogOutput{},
+		&model.RestoreTest{}, &model.NotificationTemplate{},
+		&model.Alert{}, &model.AlertEvent{}, &model.MaintenanceWindow{},
+		&model.OnCallRotation{}, &model.OnCallOverride{}, &model.NotificationAttempt{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if err := runMigrations(DB); err != nil {
+		log.Fatalf("Failed to run data migrations: %v", err)
+	}
+
 	log.Printf("Successfully connected to database: %s", AppConfig.MySQL.Database)
 } 
\ No newline at end of file
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// migration 一次性数据迁移，执行后记录在 schema_migrations 表中
+type migration struct {
+	Name string
+	Run  func(db *gorm.DB) error
+}
+
+// migrations 按顺序执行的数据迁移列表，只能追加
+var migrations = []migration{
+	{Name: "20250301_import_legacy_backup_logs", Run: importLegacyBackupLogs},
+	{Name: "20250315_parse_backup_log_times", Run: parseBackupLogTimes},
+	{Name: "20250401_normalize_backup_addresses", Run: normalizeBackupAddresses},
+}
+
+// runMigrations 执行尚未执行过的数据迁移
+func runMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&model.SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var count int64
+		if err := db.Model(&model.SchemaMigration{}).Where("name = ?", m.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		log.Printf("Running migration %s", m.Name)
+		// 迁移与其执行记录在同一事务中提交，中途失败时整体回滚，下次启动重新执行不会重复导入
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&model.SchemaMigration{Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyBackupLogTables 原独立 backup_api 服务 (BackupLogs) 及旧版主服务 (backuplogs) 使用的备份日志表
+var legacyBackupLogTables = []string{"BackupLogs", "backuplogs"}
+
+// importLegacyBackupLogs 将旧备份日志表中的记录导入 backup_logs 表，原表保留不删除
+func importLegacyBackupLogs(db *gorm.DB) error {
+	// 按 information_schema 中的实际表名导入：表名大小写不敏感的 MySQL 上两个名称指向同一张表，只返回一行；
+	// 大小写敏感时两张表都存在则依次导入
+	var tables []string
+	err := db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name IN ?",
+		legacyBackupLogTables).Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := importLegacyBackupLogTable(db, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importLegacyBackupLogTable 导入一张旧备份日志表。原 Id 未被占用时保留，使正在运行的脚本仍能用原 Id 更新记录，
+// 已被占用的记录分配新 Id
+func importLegacyBackupLogTable(db *gorm.DB, table string) error {
+	var rows []legacyBackupLog
+	imported, renumbered := 0, 0
+	result := db.Table(table).Order("Id").FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.Id)
+		}
+		var taken []uint
+		if err := db.Model(&model.BackupLog{}).Where("id IN ?", ids).Pluck("id", &taken).Error; err != nil {
+			return err
+		}
+		collides := make(map[uint]bool, len(taken))
+		for _, id := range taken {
+			collides[id] = true
+		}
+
+		var kept, moved []model.BackupLog
+		for _, row := range rows {
+			l := row.toBackupLog()
+			if collides[l.Id] {
+				l.Id = 0
+				moved = append(moved, l)
+			} else {
+				kept = append(kept, l)
+			}
+		}
+		// 保留原 Id 和分配新 Id 的记录分开插入，避免同一条 INSERT 中混用显式 Id 和自增 Id
+		for _, logs := range [][]model.BackupLog{kept, moved} {
+			if len(logs) == 0 {
+				continue
+			}
+			if err := db.Create(&logs).Error; err != nil {
+				return err
+			}
+		}
+		imported += len(rows)
+		renumbered += len(moved)
+		return nil
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Printf("Imported %d rows from legacy table %s (%d renumbered)", imported, table, renumbered)
+	return nil
+}
+
+// legacyBackupLog 旧备份日志表的一行，时间字段为脚本上报的原始字符串
+type legacyBackupLog struct {
+	Id            uint   `gorm:"column:Id;primaryKey"`
+	Ip            string `gorm:"column:Ip"`
+	ServerName    string `gorm:"column:ServerName"`
+	StartTime     string `gorm:"column:StartTime"`
+	EndTime       string `gorm:"column:EndTime"`
+	BackupStatus  int    `gorm:"column:BackupStatus"`
+	AlertStatus   int    `gorm:"column:AlertStatus"`
+	ScriptVersion string `gorm:"column:ScriptVersion"`
+}
+
+func (row legacyBackupLog) toBackupLog() model.BackupLog {
+	l := model.BackupLog{
+		Id:            row.Id,
+		Ip:            row.Ip,
+		ServerName:    row.ServerName,
+		StartTime:     parseLegacyTime(row.Id, row.StartTime),
+		EndTime:       parseLegacyTime(row.Id, row.EndTime),
+		BackupStatus:  row.BackupStatus,
+		AlertStatus:   row.AlertStatus,
+		ScriptVersion: row.ScriptVersion,
+		CreatedAt:     time.Now(),
+	}
+	if !l.StartTime.IsZero() {
+		l.CreatedAt = l.StartTime.Time
+	}
+	return l
+}
+
+// parseLegacyTime 解析旧数据中的时间字符串，无法解析时记录日志并返回零值
+func parseLegacyTime(id uint, s string) model.BackupTime {
+	t, err := model.ParseBackupTime(s)
+	if err != nil {
+		log.Printf("Backup log %d: %v", id, err)
+	}
+	return model.BackupTime{Time: t}
+}
+
+// prepareMigrations 在自动迁移之前执行的结构调整，必须可重复执行
+func prepareMigrations(db *gorm.DB) error {
+	return renameUntypedBackupTimeColumns(db)
+}
+
+// renameUntypedBackupTimeColumns 将字符串类型的 start_time/end_time 列改名为 *_raw，
+// 由自动迁移创建新的时间类型列，再由 parseBackupLogTimes 迁移数据
+func renameUntypedBackupTimeColumns(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.BackupLog{}) {
+		return nil
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(&model.BackupLog{})
+	if err != nil {
+		return err
+	}
+	for _, ct := range columnTypes {
+		name := ct.Name()
+		if name != "start_time" && name != "end_time" {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(ct.DatabaseTypeName()), "char") {
+			continue
+		}
+		log.Printf("Renaming backup_logs.%s to %s_raw", name, name)
+		if err := db.Exec("ALTER TABLE backup_logs CHANGE " + name + " " + name + "_raw VARCHAR(255) NULL").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBackupLogTimes 将 *_raw 列中的字符串时间解析到时间类型列，并计算耗时
+func parseBackupLogTimes(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&model.BackupLog{}, "start_time_raw") {
+		return nil
+	}
+
+	type rawTimes struct {
+		Id           uint `gorm:"primaryKey"`
+		StartTimeRaw string
+		EndTimeRaw   string
+	}
+	var rows []rawTimes
+	result := db.Table("backup_logs").Select("id, COALESCE(start_time_raw, '') AS start_time_raw, COALESCE(end_time_raw, '') AS end_time_raw").
+		Where("start_time_raw IS NOT NULL OR end_time_raw IS NOT NULL").
+		FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				l := model.BackupLog{
+					StartTime: parseLegacyTime(row.Id, row.StartTimeRaw),
+					EndTime:   parseLegacyTime(row.Id, row.EndTimeRaw),
+				}
+				l.ComputeDuration()
+				err := db.Model(&model.BackupLog{}).Where("id = ?", row.Id).UpdateColumns(map[string]interface{}{
+					"start_time":       l.StartTime,
+					"end_time":         l.EndTime,
+					"duration_seconds": l.DurationSeconds,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, column := range []string{"start_time_raw", "end_time_raw"} {
+		if db.Migrator().HasColumn(&model.BackupLog{}, column) {
+			if err := db.Migrator().DropColumn(&model.BackupLog{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backupAddressColumns 保存备份相关地址的表和列
+var backupAddressColumns = []struct{ Table, Column string }{
+	{"backup_logs", "ip"},
+	{"backup_sources", "ip"},
+	{"server_addresses", "ip"},
+	{"agent_tokens", "ip"},
+	{"agent_tokens", "source_ip"},
+	{"backup_alert_states", "ip"},
+}
+
+// normalizeBackupAddresses 将已有地址改写为规范形式，并填充 backup_logs.ip_bin；
+// 无法解析或改写后与已有记录冲突的地址保持原样并记录日志
+func normalizeBackupAddresses(db *gorm.DB) error {
+	for _, tc := range backupAddressColumns {
+		var ips []string
+		if err := db.Table(tc.Table).Where(tc.Column+" <> ''").Distinct(tc.Column).Pluck(tc.Column, &ips).Error; err != nil {
+			return err
+		}
+		for _, ip := range ips {
+			normalized, err := model.NormalizeIP(ip)
+			if err != nil {
+				log.Printf("%s.%s: %v", tc.Table, tc.Column, err)
+				continue
+			}
+			updates := map[string]interface{}{tc.Column: normalized}
+			if tc.Table == "backup_logs" {
+				updates["ip_bin"] = model.IPBytes(normalized)
+			} else if normalized == ip {
+				continue
+			}
+			if err := db.Table(tc.Table).Where(tc.Column+" = ?", ip).UpdateColumns(updates).Error; err != nil {
+				log.Printf("%s.%s: failed to normalize %q: %v", tc.Table, tc.Column, ip, err)
+			}
+		}
+	}
+	return nil
+}
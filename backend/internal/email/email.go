@@ -1,13 +1,25 @@
 package email
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"net/smtp"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
 	"strings"
+	"time"
 
 	"github.com/go-ssl-monitor/internal/config"
 )
 
+// ErrDisabled 邮件功能未启用（email.enabled 为 false）
+var ErrDisabled = errors.New("邮件功能未启用")
+
 type EmailSender struct {
 	config *config.EmailConfig
 }
@@ -18,40 +30,159 @@ func NewEmailSender(config *config.EmailConfig) *EmailSender {
 	}
 }
 
-func (e *EmailSender) SendAlertEmail(ip, serverName string, backupError string) error {
-	// 如果邮件配置未启用，直接返回
-	if e.config == nil || e.config.SMTPHost == "" {
-		return fmt.Errorf("email configuration not set")
+// Send 发送纯文本邮件，收件人为空时使用配置的默认收件人
+func (e *EmailSender) Send(to []string, subject, body string) error {
+	_, err := e.SendMessage(to, Message{Subject: subject, Text: body})
+	return err
+}
+
+// SendMessage 发送邮件，包含 HTML 部分时以 multipart/alternative 发送，返回 SMTP 服务器接受邮件时的应答
+func (e *EmailSender) SendMessage(to []string, msg Message) (string, error) {
+	if e.config == nil || !e.config.Enabled {
+		return "", ErrDisabled
+	}
+	if e.config.SMTPHost == "" {
+		return "", fmt.Errorf("email configuration not set")
+	}
+	if len(to) == 0 {
+		to = e.config.ToAddresses
+	}
+	if len(to) == 0 {
+		return "", fmt.Errorf("没有收件人")
+	}
+
+	from := e.config.FromAddress
+	if from == "" {
+		from = e.config.Username
 	}
+	data, err := buildMessage(mail.Address{Name: e.config.FromName, Address: from}, to, msg)
+	if err != nil {
+		return "", err
+	}
+	return newTransport(e.config).send(from, to, data)
+}
 
-	auth := smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.SMTPHost)
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"`
+}
 
-	subject := "备份异常告警通知"
-	body := fmt.Sprintf(`
-服务器备份异常告警：
+// buildMessage 生成邮件内容：From、To、Date、Message-ID 头，RFC 2047 编码的主题，base64 编码的正文；
+// 有附件时以 multipart/mixed 发送
+func buildMessage(from mail.Address, to []string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID(from.Address))
+	buf.WriteString("MIME-Version: 1.0\r\n")
 
-IP地址: %s
-服务器名称: %s
-错误信息: %s
+	var body bytes.Buffer
+	header, err := writeBody(&body, msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) == 0 {
+		for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if v := header.Get(key); v != "" {
+				fmt.Fprintf(&buf, "%s: %s\r\n", key, v)
+			}
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body.Bytes())
+		return buf.Bytes(), nil
+	}
 
-请及时检查并处理。
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+	w, err := mixed.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": a.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var encoded bytes.Buffer
+		writeBase64(&encoded, a.Data)
+		if _, err := w.Write(encoded.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-此邮件为系统自动发送，请勿回复。
-`, ip, serverName, backupError)
+// writeBody 写入正文（纯文本，或包含 HTML 时为 multipart/alternative），返回正文对应的 MIME 头
+func writeBody(buf *bytes.Buffer, msg Message) (textproto.MIMEHeader, error) {
+	if msg.HTML == "" {
+		writeBase64(buf, []byte(msg.Text))
+		return textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"base64"},
+		}, nil
+	}
 
-	msg := []byte(fmt.Sprintf("To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"Content-Type: text/plain; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s\r\n", strings.Join(e.config.ToAddresses, ","), subject, body))
+	mw := multipart.NewWriter(buf)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var encoded bytes.Buffer
+		writeBase64(&encoded, []byte(part.content))
+		if _, err := w.Write(encoded.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+	}, nil
+}
 
-	err := smtp.SendMail(
-		fmt.Sprintf("%s:%d", e.config.SMTPHost, e.config.SMTPPort),
-		auth,
-		e.config.FromAddress,
-		e.config.ToAddresses,
-		msg,
-	)
+// messageID 生成 <时间.随机数@发件域名> 形式的 Message-ID
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
 
-	return err
-} 
\ No newline at end of file
+// writeBase64 以每行 76 个字符写入 base64 编码的内容
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
@@ -0,0 +1,43 @@
+package email
+
+import (
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// BackupAlertMessage 渲染备份异常告警邮件（模板 backup_alert）
+func BackupAlertMessage(db *gorm.DB, ip, serverName string, backupError string) Message {
+	return RenderMessage(db, "backup_alert", map[string]interface{}{
+		"IP":         ip,
+		"ServerName": serverName,
+		"Error":      backupError,
+	})
+}
+
+// BackupRecoveryMessage 渲染备份恢复正常通知（模板 backup_recovery）
+func BackupRecoveryMessage(db *gorm.DB, ip, serverName string, failures int) Message {
+	return RenderMessage(db, "backup_recovery", map[string]interface{}{
+		"IP":         ip,
+		"ServerName": serverName,
+		"Failures":   failures,
+	})
+}
+
+// DomainAlertMessage 渲染域名证书告警邮件（模板 domain_alert）
+func DomainAlertMessage(db *gorm.DB, domain *model.Domain, message string) Message {
+	data := map[string]interface{}{
+		"Domain":  domain.DomainName,
+		"Message": message,
+		"Issuer":  domain.CertificateIssuer,
+		"Status":  domain.CertificateStatus,
+	}
+	if !domain.CertificateExpiryDate.IsZero() {
+		data["ExpiryDate"] = domain.CertificateExpiryDate.Format("2006-01-02 15:04:05")
+	}
+	return RenderMessage(db, "domain_alert", data)
+}
+
+// DigestMessage 渲染汇总报告邮件（模板 digest）
+func DigestMessage(db *gorm.DB, data map[string]interface{}) Message {
+	return RenderMessage(db, "digest", data)
+}
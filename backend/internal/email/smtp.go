@@ -0,0 +1,253 @@
+package email
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// 加密方式 (email.tls_mode)
+const (
+	TLSModeImplicit      = "tls"           // 连接即使用 TLS，通常为 465 端口
+	TLSModeSTARTTLS      = "starttls"      // 必须使用 STARTTLS，服务器不支持时发送失败
+	TLSModeOpportunistic = "opportunistic" // 服务器支持时使用 STARTTLS
+	TLSModeNone          = "none"          // 不加密
+)
+
+// 认证方式 (email.auth)
+const (
+	AuthAuto    = ""     // 配置了用户名时使用服务器支持的 PLAIN 或 LOGIN，否则不认证
+	AuthNone    = "none" // 不认证，用于内部中继
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+type transport struct {
+	cfg     *config.EmailConfig
+	mode    string
+	addr    string
+	timeout time.Duration
+}
+
+func newTransport(cfg *config.EmailConfig) *transport {
+	mode := strings.ToLower(cfg.TLSMode)
+	if mode == "" {
+		mode = TLSModeOpportunistic
+		if cfg.SMTPPort == 465 {
+			mode = TLSModeImplicit
+		}
+	}
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 25
+		if mode == TLSModeImplicit {
+			port = 465
+		}
+	}
+	timeout := 30 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	return &transport{cfg: cfg, mode: mode, addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)), timeout: timeout}
+}
+
+func (t *transport) tlsConfig() (*tls.Config, error) {
+	tc := &tls.Config{ServerName: t.cfg.SMTPHost, InsecureSkipVerify: t.cfg.InsecureSkipVerify}
+	if t.cfg.CAFile != "" {
+		pem, err := os.ReadFile(t.cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA证书文件中没有有效的证书: %s", t.cfg.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+// send 建立连接并发送一封邮件，整个会话受超时限制，返回服务器接受邮件时的应答
+func (t *transport) send(from string, to []string, data []byte) (string, error) {
+	switch t.mode {
+	case TLSModeImplicit, TLSModeSTARTTLS, TLSModeOpportunistic, TLSModeNone:
+	default:
+		return "", fmt.Errorf("不支持的加密方式: %s", t.mode)
+	}
+	tc, err := t.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+
+	dialer := &net.Dialer{Timeout: t.timeout}
+	var conn net.Conn
+	if t.mode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.addr, tc)
+	} else {
+		conn, err = dialer.Dial("tcp", t.addr)
+	}
+	if err != nil {
+		return "", err
+	}
+	conn.SetDeadline(time.Now().Add(t.timeout))
+
+	c, err := smtp.NewClient(conn, t.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer c.Close()
+
+	if t.cfg.HeloName != "" {
+		if err := c.Hello(t.cfg.HeloName); err != nil {
+			return "", err
+		}
+	}
+
+	if t.mode == TLSModeSTARTTLS || t.mode == TLSModeOpportunistic {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tc); err != nil {
+				return "", fmt.Errorf("STARTTLS失败: %w", err)
+			}
+		} else if t.mode == TLSModeSTARTTLS {
+			return "", errors.New("服务器不支持 STARTTLS")
+		}
+	}
+
+	auth, err := t.auth(c)
+	if err != nil {
+		return "", err
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return "", fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return "", err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(strings.TrimSpace(rcpt)); err != nil {
+			return "", fmt.Errorf("收件人 %s: %w", rcpt, err)
+		}
+	}
+	reply, err := sendData(c, data)
+	if err != nil {
+		return "", err
+	}
+	// 服务器已接受邮件，QUIT 失败不影响投递，不能视为发送失败，否则重试会重复发送
+	if err := c.Quit(); err != nil {
+		log.Printf("SMTP QUIT to %s failed after message was accepted: %v", t.addr, err)
+	}
+	return reply, nil
+}
+
+// sendData 与 smtp.Client.Data 相同，但保留服务器接受邮件时的应答（如 "250 2.0.0 Ok: queued as ..."）用于发送记录
+func sendData(c *smtp.Client, data []byte) (string, error) {
+	id, err := c.Text.Cmd("DATA")
+	if err != nil {
+		return "", err
+	}
+	c.Text.StartResponse(id)
+	_, _, err = c.Text.ReadResponse(354)
+	c.Text.EndResponse(id)
+	if err != nil {
+		return "", err
+	}
+	w := c.Text.DotWriter()
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	code, msg, err := c.Text.ReadResponse(250)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s", code, msg), nil
+}
+
+// auth 根据配置和服务器支持的机制选择认证方式，返回 nil 表示不认证
+func (t *transport) auth(c *smtp.Client) (smtp.Auth, error) {
+	cfg := t.cfg
+	mechanism := strings.ToLower(cfg.Auth)
+	if mechanism == AuthNone || (mechanism == AuthAuto && cfg.Username == "") {
+		return nil, nil
+	}
+
+	ok, advertised := c.Extension("AUTH")
+	if !ok {
+		if mechanism == AuthAuto {
+			return nil, nil
+		}
+		return nil, errors.New("服务器不支持 SMTP 认证")
+	}
+	if mechanism == AuthAuto {
+		mechanism = AuthPlain
+		if !strings.Contains(" "+strings.ToUpper(advertised)+" ", " PLAIN ") &&
+			strings.Contains(" "+strings.ToUpper(advertised)+" ", " LOGIN ") {
+			mechanism = AuthLogin
+		}
+	}
+
+	switch mechanism {
+	case AuthPlain:
+		return smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost), nil
+	case AuthLogin:
+		return &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.SMTPHost}, nil
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(cfg.Username, cfg.Password), nil
+	default:
+		return nil, fmt.Errorf("不支持的认证方式: %s", cfg.Auth)
+	}
+}
+
+// loginAuth 实现 AUTH LOGIN，与 smtp.PlainAuth 一样只在加密连接或本机上发送密码
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,387 @@
+package email
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// fakeSMTP 只接受一个连接的 SMTP 服务器，记录客户端的命令、认证信息和邮件内容
+type fakeSMTP struct {
+	t         *testing.T
+	ln        net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool   // 是否声明 STARTTLS
+	mechs     string // 声明的 AUTH 机制，为空时不支持认证
+	password  string // CRAM-MD5 校验使用的密码
+	dropQuit  bool   // 收到 QUIT 后直接断开连接，不应答
+
+	mu       sync.Mutex
+	commands []string // 收到的命令，TLS 连接上的命令带 "tls:" 前缀
+	auth     []string // 解码后的认证信息
+	data     []byte
+	done     chan struct{}
+}
+
+// newFakeSMTP 启动假 SMTP 服务器，implicitTLS 为 true 时监听 TLS；返回服务器和可信任该服务器证书的 CA 文件
+func newFakeSMTP(t *testing.T, implicitTLS bool) (*fakeSMTP, string) {
+	t.Helper()
+	cert, caFile := newTestCertificate(t)
+	s := &fakeSMTP{t: t, tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}, done: make(chan struct{})}
+	var err error
+	if implicitTLS {
+		s.ln, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { s.ln.Close() })
+	go s.serve(implicitTLS)
+	return s, caFile
+}
+
+// config 返回连接该服务器的邮件配置
+func (s *fakeSMTP) config(tlsMode, caFile string) *config.EmailConfig {
+	return &config.EmailConfig{
+		Enabled:     true,
+		SMTPHost:    "127.0.0.1",
+		SMTPPort:    s.ln.Addr().(*net.TCPAddr).Port,
+		TLSMode:     tlsMode,
+		CAFile:      caFile,
+		FromAddress: "monitor@example.com",
+		ToAddresses: []string{"ops@example.com"},
+		Timeout:     5,
+	}
+}
+
+func (s *fakeSMTP) serve(isTLS bool) {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tc := textproto.NewConn(conn)
+	tc.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+		s.record(isTLS, verb)
+
+		switch verb {
+		case "EHLO", "HELO":
+			lines := []string{"fake"}
+			if s.startTLS && !isTLS {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.mechs != "" {
+				lines = append(lines, "AUTH "+s.mechs)
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tc.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			tc.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, tc, isTLS = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			if !s.authenticate(tc, arg) {
+				tc.PrintfLine("535 authentication failed")
+				continue
+			}
+			tc.PrintfLine("235 authenticated")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			tc.PrintfLine("250 ok")
+		case "DATA":
+			tc.PrintfLine("354 go ahead")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = data
+			s.mu.Unlock()
+			tc.PrintfLine("250 2.0.0 Ok: queued as ABC123")
+		case "QUIT":
+			if !s.dropQuit {
+				tc.PrintfLine("221 bye")
+			}
+			return
+		default:
+			tc.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTP) authenticate(tc *textproto.Conn, arg string) bool {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	readLine := func() string {
+		line, _ := tc.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(initial)
+		s.addAuth(append([]string{"PLAIN"}, strings.Split(string(decoded), "\x00")...)...)
+	case "LOGIN":
+		tc.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+		username := readLine()
+		tc.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+		s.addAuth("LOGIN", username, readLine())
+	case "CRAM-MD5":
+		challenge := "<1896.697170952@fake>"
+		tc.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(challenge)))
+		username, digest, _ := strings.Cut(readLine(), " ")
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(challenge))
+		if digest != hex.EncodeToString(mac.Sum(nil)) {
+			return false
+		}
+		s.addAuth("CRAM-MD5", username)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *fakeSMTP) record(isTLS bool, verb string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if isTLS {
+		verb = "tls:" + verb
+	}
+	s.commands = append(s.commands, verb)
+}
+
+func (s *fakeSMTP) addAuth(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = append(s.auth, strings.Join(values, "|"))
+}
+
+// wait 等待会话结束，返回收到的命令、认证信息和邮件内容
+func (s *fakeSMTP) wait() ([]string, []string, []byte) {
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("SMTP session did not finish")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands, s.auth, s.data
+}
+
+// newTestCertificate 生成 127.0.0.1 的自签名证书，返回证书和其 PEM 文件路径
+func newTestCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake smtp"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSendImplicitTLS(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, true)
+	reply, err := NewEmailSender(srv.config(TLSModeImplicit, caFile)).SendMessage(nil, Message{Subject: "test", Text: "hello"})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if reply != "250 2.0.0 Ok: queued as ABC123" {
+		t.Errorf("reply = %q", reply)
+	}
+	commands, _, data := srv.wait()
+	if !contains(commands, "tls:MAIL") || !contains(commands, "tls:DATA") {
+		t.Errorf("message was not sent over TLS: %v", commands)
+	}
+	if !bytes.Contains(data, []byte("To: ops@example.com")) {
+		t.Errorf("message does not use default recipients:\n%s", data)
+	}
+}
+
+func TestSendImplicitTLSVerifiesCertificate(t *testing.T) {
+	srv, _ := newFakeSMTP(t, true)
+	if _, err := NewEmailSender(srv.config(TLSModeImplicit, "")).SendMessage(nil, Message{Subject: "test", Text: "hello"}); err == nil {
+		t.Fatal("SendMessage succeeded with an untrusted certificate")
+	}
+}
+
+func TestSendSTARTTLSUpgradesConnection(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	srv.startTLS = true
+	if _, err := NewEmailSender(srv.config(TLSModeSTARTTLS, caFile)).SendMessage(nil, Message{Subject: "test", Text: "hello"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	commands, _, _ := srv.wait()
+	if !contains(commands, "STARTTLS") || !contains(commands, "tls:MAIL") || contains(commands, "MAIL") {
+		t.Errorf("MAIL was not sent after STARTTLS: %v", commands)
+	}
+}
+
+func TestSendSTARTTLSRequiredButUnsupported(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	_, err := NewEmailSender(srv.config(TLSModeSTARTTLS, caFile)).SendMessage(nil, Message{Subject: "test", Text: "hello"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("err = %v, want STARTTLS error", err)
+	}
+	commands, _, _ := srv.wait()
+	if contains(commands, "MAIL") || contains(commands, "AUTH") {
+		t.Errorf("client continued without TLS: %v", commands)
+	}
+}
+
+func TestSendOpportunisticWithoutSTARTTLS(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	if _, err := NewEmailSender(srv.config(TLSModeOpportunistic, caFile)).SendMessage(nil, Message{Subject: "test", Text: "hello"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if commands, _, _ := srv.wait(); !contains(commands, "MAIL") {
+		t.Errorf("message was not sent in plain text: %v", commands)
+	}
+}
+
+func TestSendAuthLogin(t *testing.T) {
+	for _, mechanism := range []string{AuthLogin, AuthAuto} {
+		t.Run("auth="+mechanism, func(t *testing.T) {
+			srv, caFile := newFakeSMTP(t, false)
+			srv.startTLS = true
+			srv.mechs = "LOGIN"
+			cfg := srv.config(TLSModeSTARTTLS, caFile)
+			cfg.Auth, cfg.Username, cfg.Password = mechanism, "monitor", "p@ss"
+
+			if _, err := NewEmailSender(cfg).SendMessage(nil, Message{Subject: "test", Text: "hello"}); err != nil {
+				t.Fatalf("SendMessage: %v", err)
+			}
+			if _, auth, _ := srv.wait(); len(auth) != 1 || auth[0] != "LOGIN|monitor|p@ss" {
+				t.Errorf("auth = %v, want LOGIN with credentials", auth)
+			}
+		})
+	}
+}
+
+func TestSendAuthCRAMMD5(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	srv.mechs = "CRAM-MD5 PLAIN"
+	srv.password = "p@ss"
+	cfg := srv.config(TLSModeNone, caFile)
+	cfg.Auth, cfg.Username, cfg.Password = AuthCRAMMD5, "monitor", "p@ss"
+
+	if _, err := NewEmailSender(cfg).SendMessage(nil, Message{Subject: "test", Text: "hello"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if _, auth, _ := srv.wait(); len(auth) != 1 || auth[0] != "CRAM-MD5|monitor" {
+		t.Errorf("auth = %v, want CRAM-MD5", auth)
+	}
+}
+
+func TestSendAuthCRAMMD5WrongPassword(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	srv.mechs = "CRAM-MD5"
+	srv.password = "p@ss"
+	cfg := srv.config(TLSModeNone, caFile)
+	cfg.Auth, cfg.Username, cfg.Password = AuthCRAMMD5, "monitor", "wrong"
+
+	_, err := NewEmailSender(cfg).SendMessage(nil, Message{Subject: "test", Text: "hello"})
+	if err == nil || !strings.Contains(err.Error(), "SMTP认证失败") {
+		t.Fatalf("err = %v, want authentication failure", err)
+	}
+}
+
+func TestSendEncodesSubject(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	subject := "[SSL监控] 备份失败：服务器 10.0.0.1"
+	if _, err := NewEmailSender(srv.config(TLSModeNone, caFile)).SendMessage(nil, Message{Subject: subject, Text: "连续失败 3 次"}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	_, _, data := srv.wait()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	raw := msg.Header.Get("Subject")
+	if !strings.HasPrefix(raw, "=?UTF-8?b?") {
+		t.Errorf("Subject is not RFC 2047 encoded: %q", raw)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(raw)
+	if err != nil || decoded != subject {
+		t.Errorf("decoded Subject = %q (%v), want %q", decoded, err, subject)
+	}
+}
+
+func TestSendIgnoresQuitFailure(t *testing.T) {
+	srv, caFile := newFakeSMTP(t, false)
+	srv.dropQuit = true
+	reply, err := NewEmailSender(srv.config(TLSModeNone, caFile)).SendMessage(nil, Message{Subject: "test", Text: "hello"})
+	if err != nil {
+		t.Fatalf("SendMessage returned %v after the message was accepted", err)
+	}
+	if reply != "250 2.0.0 Ok: queued as ABC123" {
+		t.Errorf("reply = %q", reply)
+	}
+}
@@ -0,0 +1,211 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// 模板来源
+const (
+	SourceDatabase = "database"
+	SourceFile     = "file"
+	SourceBuiltin  = "builtin"
+)
+
+// DefaultLanguage 未配置语言或模板缺少该语言版本时使用的语言
+const DefaultLanguage = "zh"
+
+// Languages 支持的模板语言
+var Languages = []string{"zh", "en"}
+
+// TemplateNames 可编辑的模板名称
+var TemplateNames = []string{"backup_alert", "backup_recovery", "domain_alert", "digest"}
+
+// Message 渲染后的邮件内容，HTML 为空时只发送纯文本
+type Message struct {
+	Subject     string       `json:"subject"`
+	Text        string       `json:"text"`
+	HTML        string       `json:"html"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Template 一个模板的主题、纯文本和 HTML 部分
+type Template struct {
+	Name    string `json:"name"`
+	Lang    string `json:"lang"`
+	Source  string `json:"source"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// LoadTemplate 按数据库、模板目录、内置模板的顺序查找模板，找不到该语言时使用默认语言
+func LoadTemplate(db *gorm.DB, name, lang string) (*Template, error) {
+	langs := []string{lang}
+	if lang != DefaultLanguage {
+		langs = append(langs, DefaultLanguage)
+	}
+	for _, l := range langs {
+		if db != nil {
+			var t model.NotificationTemplate
+			if err := db.Where("name = ? AND lang = ?", name, l).First(&t).Error; err == nil {
+				return &Template{Name: name, Lang: l, Source: SourceDatabase, Subject: t.Subject, Text: t.Text, HTML: t.HTML}, nil
+			}
+		}
+		if dir := config.AppConfig.Email.TemplateDir; dir != "" {
+			if t, ok := readTemplate(os.DirFS(dir), name, l); ok {
+				t.Source = SourceFile
+				return t, nil
+			}
+		}
+		if t, ok := readTemplate(builtinTemplates, "templates/"+name, l); ok {
+			t.Source = SourceBuiltin
+			t.Name = name
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("模板不存在: %s", name)
+}
+
+// readTemplate 读取 <name>.<lang>.subject.tmpl、.txt.tmpl、.html.tmpl，主题和纯文本部分必须存在
+func readTemplate(fsys fs.FS, name, lang string) (*Template, bool) {
+	read := func(part string) (string, bool) {
+		b, err := fs.ReadFile(fsys, fmt.Sprintf("%s.%s.%s.tmpl", name, lang, part))
+		return string(b), err == nil
+	}
+	subject, ok := read("subject")
+	if !ok {
+		return nil, false
+	}
+	text, ok := read("txt")
+	if !ok {
+		return nil, false
+	}
+	html, _ := read("html")
+	return &Template{Name: name, Lang: lang, Subject: subject, Text: text, HTML: html}, true
+}
+
+// Render 渲染模板，纯文本部分使用 text/template，HTML 部分使用 html/template 自动转义
+func (t *Template) Render(data map[string]interface{}) (Message, error) {
+	var msg Message
+	var err error
+	if msg.Subject, err = renderText(t.Name+".subject", t.Subject, data); err != nil {
+		return msg, err
+	}
+	if msg.Text, err = renderText(t.Name+".txt", t.Text, data); err != nil {
+		return msg, err
+	}
+	if t.HTML != "" {
+		tmpl, err := htmltemplate.New(t.Name + ".html").Option("missingkey=zero").Parse(t.HTML)
+		if err != nil {
+			return msg, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return msg, err
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
+
+func renderText(name, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := texttemplate.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Validate 检查模板能否解析并用示例数据渲染
+func (t *Template) Validate() error {
+	_, err := t.Render(SampleData(t.Name))
+	return err
+}
+
+// RenderMessage 使用配置的语言渲染模板；自定义模板渲染失败时退回内置模板
+func RenderMessage(db *gorm.DB, name string, data map[string]interface{}) Message {
+	data["Time"] = time.Now().Format("2006-01-02 15:04:05")
+	lang := config.AppConfig.Email.Language
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+
+	t, err := LoadTemplate(db, name, lang)
+	if err == nil {
+		msg, renderErr := t.Render(data)
+		if renderErr == nil {
+			return msg
+		}
+		err = renderErr
+	}
+	log.Printf("Email template %s (%s): %v, using built-in template", name, lang, err)
+
+	if t, ok := readTemplate(builtinTemplates, "templates/"+name, DefaultLanguage); ok {
+		t.Name = name
+		if msg, err := t.Render(data); err == nil {
+			return msg
+		}
+	}
+	return Message{Subject: name, Text: fmt.Sprint(data)}
+}
+
+// SampleData 返回用于模板预览的示例数据
+func SampleData(name string) map[string]interface{} {
+	data := map[string]interface{}{"Time": time.Now().Format("2006-01-02 15:04:05")}
+	switch name {
+	case "backup_alert":
+		data["IP"] = "10.2.0.15"
+		data["ServerName"] = "db-backup-01"
+		data["Error"] = "备份执行失败（连续失败 2 次）\n退出码: 23\n\n脚本输出（末尾部分）:\nrsync error: some files could not be transferred (code 23)"
+	case "backup_recovery":
+		data["IP"] = "10.2.0.15"
+		data["ServerName"] = "db-backup-01"
+		data["Failures"] = 3
+	case "domain_alert":
+		data["Domain"] = "example.com"
+		data["Message"] = "证书将在 7 天后过期"
+		data["Issuer"] = "R3"
+		data["ExpiryDate"] = time.Now().AddDate(0, 0, 7).Format("2006-01-02 15:04:05")
+		data["Status"] = "WARNING"
+	case "digest":
+		now := time.Now()
+		data["Name"] = "weekly"
+		data["GeneratedAt"] = now.Format("2006-01-02 15:04:05 MST")
+		data["PeriodStart"] = now.AddDate(0, 0, -7).Format("2006-01-02 15:04")
+		data["PeriodEnd"] = now.Format("2006-01-02 15:04")
+		data["ExpiryDays"] = 30
+		data["PeriodDays"] = 7
+		data["ExpiringCerts"] = []map[string]interface{}{
+			{"Domain": "example.com", "Status": "VALID", "Issuer": "R3", "ExpiryDate": now.AddDate(0, 0, 12).Format("2006-01-02 15:04:05"), "DaysLeft": 12},
+		}
+		data["ErrorCerts"] = []map[string]interface{}{
+			{"Domain": "legacy.example.com", "Status": "ERROR", "ExpiryDate": now.AddDate(0, 0, -3).Format("2006-01-02 15:04:05"), "DaysLeft": -3, "Reason": "证书已过期"},
+		}
+		data["BackupStats"] = []map[string]interface{}{
+			{"Ip": "10.2.0.15", "ServerName": "db-backup-01", "Runs": 7, "Finished": 7, "Succeeded": 6, "SuccessRate": "85.7%"},
+		}
+		data["MissingBackups"] = []map[string]interface{}{
+			{"Ip": "10.2.0.16", "ServerName": "file-backup-02", "Message": "计划于 " + now.Format("2006-01-02") + " 02:00:00 执行的备份未在 30 分钟内开始"},
+		}
+	}
+	return data
+}
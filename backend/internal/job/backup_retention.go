@@ -0,0 +1,216 @@
+package job
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartBackupRetention 启动后台任务，定期将超过保留期的备份日志归档、汇总后删除
+func StartBackupRetention(db *gorm.DB, retention config.BackupRetention) {
+	if retention.RawDays <= 0 {
+		log.Printf("Backup retention disabled")
+		return
+	}
+	interval := retention.IntervalDuration()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := ApplyBackupRetention(db, retention, time.Now()); err != nil {
+				log.Printf("Backup retention failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("Backup retention started, keeping %d days of raw logs, interval %s", retention.RawDays, interval)
+}
+
+// ApplyBackupRetention 按天处理早于保留期的备份日志：先导出到归档文件，再在同一事务中写入每日汇总并删除原始记录；
+// 最后删除超过汇总保留期的每日汇总
+func ApplyBackupRetention(db *gorm.DB, retention config.BackupRetention, now time.Time) error {
+	cutoff := startOfDay(now.AddDate(0, 0, -retention.RawDays))
+	runTime := "COALESCE(start_time, created_at)"
+
+	for {
+		var oldest struct{ Day *time.Time }
+		if err := db.Model(&model.BackupLog{}).Select("MIN("+runTime+") AS day").
+			Where(runTime+" < ?", cutoff).Scan(&oldest).Error; err != nil {
+			return err
+		}
+		if oldest.Day == nil {
+			break
+		}
+
+		day := startOfDay(*oldest.Day)
+		var logs []model.BackupLog
+		if err := db.Where(runTime+" >= ? AND "+runTime+" < ?", day, day.AddDate(0, 0, 1)).
+			Order("id ASC").Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		if retention.ArchiveDir != "" {
+			if err := archiveBackupLogs(db, retention.ArchiveDir, day, logs); err != nil {
+				return err
+			}
+		}
+		if err := rollupAndDelete(db, day, logs); err != nil {
+			return err
+		}
+		log.Printf("Archived %d backup logs from %s", len(logs), day.Format("2006-01-02"))
+	}
+
+	if retention.RollupDays > 0 {
+		rollupCutoff := startOfDay(now.AddDate(0, 0, -retention.RollupDays))
+		if err := db.Where("day < ?", rollupCutoff).Delete(&model.BackupDailyRollup{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivedBackupLog 归档文件中的一行：备份日志及其保存的脚本输出（解压后的文本）
+type archivedBackupLog struct {
+	model.BackupLog
+	Output          *string `json:"output,omitempty"`
+	OutputTruncated bool    `json:"output_truncated,omitempty"`
+}
+
+// archiveBackupLogs 将一天的备份日志连同脚本输出写入 backup_logs-YYYY-MM-DD.jsonl.gz（JSON Lines），
+// 同一天再次归档时（如补报的旧记录）写入带时间戳后缀的新文件，不覆盖已有归档
+func archiveBackupLogs(db *gorm.DB, dir string, day time.Time, logs []model.BackupLog) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := "backup_logs-" + day.Format("2006-01-02")
+	path := filepath.Join(dir, name+".jsonl.gz")
+	if _, err := os.Stat(path); err == nil {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.jsonl.gz", name, time.Now().Unix()))
+	}
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	for _, l := range logs {
+		record := archivedBackupLog{BackupLog: l}
+		// 脚本输出较大，逐条读取，避免一次载入一天的全部输出
+		if l.HasOutput {
+			var output model.BackupLogOutput
+			err := db.Where("backup_log_id = ?", l.Id).Limit(1).Find(&output).Error
+			if err == nil && output.ID != 0 {
+				var text string
+				text, err = decompressOutput(output.Content)
+				record.Output, record.OutputTruncated = &text, output.Truncated
+			}
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("读取备份日志 %d 的输出失败: %w", l.Id, err)
+			}
+		}
+		if err := enc.Encode(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// decompressOutput 解压 gzip 保存的脚本输出
+func decompressOutput(content []byte) (string, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	output, err := io.ReadAll(gz)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// rollupAndDelete 写入一天的每日汇总并删除对应的原始记录，引用这些记录的恢复验证、通知在同一事务中解除关联
+func rollupAndDelete(db *gorm.DB, day time.Time, logs []model.BackupLog) error {
+	rollups := make(map[string]*model.BackupDailyRollup)
+	var ids []uint
+	for _, l := range logs {
+		ids = append(ids, l.Id)
+		r, ok := rollups[l.Ip]
+		if !ok {
+			r = &model.BackupDailyRollup{Day: day, Ip: l.Ip}
+			rollups[l.Ip] = r
+		}
+		r.ServerID = l.ServerID
+		r.ServerName = l.ServerName
+		r.Runs++
+		switch {
+		case l.EndTime.IsZero():
+			r.Unfinished++
+		case l.BackupStatus == 0:
+			r.Successes++
+		default:
+			r.Failures++
+		}
+		r.TotalDurationSeconds += l.DurationSeconds
+		if l.DurationSeconds > r.MaxDurationSeconds {
+			r.MaxDurationSeconds = l.DurationSeconds
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, r := range rollups {
+			// 同一天的记录可能分多次归档，与已有汇总累加
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "day"}, {Name: "ip"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"server_id":              r.ServerID,
+					"server_name":            r.ServerName,
+					"runs":                   gorm.Expr("runs + ?", r.Runs),
+					"successes":              gorm.Expr("successes + ?", r.Successes),
+					"failures":               gorm.Expr("failures + ?", r.Failures),
+					"unfinished":             gorm.Expr("unfinished + ?", r.Unfinished),
+					"total_duration_seconds": gorm.Expr("total_duration_seconds + ?", r.TotalDurationSeconds),
+					"max_duration_seconds":   gorm.Expr("GREATEST(max_duration_seconds, ?)", r.MaxDurationSeconds),
+					"updated_at":             time.Now(),
+				}),
+			}).Create(r).Error
+			if err != nil {
+				return err
+			}
+		}
+		return model.DeleteBackupLogs(tx, ids)
+	})
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
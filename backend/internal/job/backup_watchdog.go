@@ -0,0 +1,133 @@
+package job
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// 备份缺失告警类型
+const (
+	AlertNotStarted  = "not_started"
+	AlertNotFinished = "not_finished"
+)
+
+// 计划时间之前多早开始的备份仍视为本次运行
+const earlyStartTolerance = 5 * time.Minute
+
+// StartBackupWatchdog 启动后台任务，定期检查预期的备份来源是否按计划开始和完成备份，以及是否有近期的恢复验证
+func StartBackupWatchdog(db *gorm.DB, interval time.Duration, restoreMaxAge int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			CheckBackupSources(db, time.Now())
+			CheckRestoreVerification(db, time.Now(), restoreMaxAge)
+			<-ticker.C
+		}
+	}()
+	log.Printf("Backup watchdog started, interval %s", interval)
+}
+
+// CheckBackupSources 检查所有启用的备份来源，对缺失或未完成的备份发送告警
+func CheckBackupSources(db *gorm.DB, now time.Time) {
+	var sources []model.BackupSource
+	if err := db.Where("enabled = ?", true).Find(&sources).Error; err != nil {
+		log.Printf("Backup watchdog: failed to load sources: %v", err)
+		return
+	}
+
+	for i := range sources {
+		source := &sources[i]
+		alertType, run, message := evaluateSource(db, source, now)
+		if alertType == "" {
+			if !run.IsZero() && source.LastAlertType != "" {
+				alert.Resolve(db, alert.BackupMissingKey(source.Ip), now)
+			}
+			continue
+		}
+		// 同一次计划运行的同类告警只发送一次
+		if source.LastAlertRun.Equal(run) && source.LastAlertType == alertType {
+			continue
+		}
+
+		target := alert.ServerTarget(db, source.Ip)
+		a, notify := alert.Raise(db, alert.Condition{
+			Key:      alert.BackupMissingKey(source.Ip),
+			Kind:     "backup_missing",
+			Severity: model.SeverityWarning,
+			Message:  message,
+			Target:   target,
+		}, now)
+		if notify {
+			msg := email.BackupAlertMessage(db, source.Ip, source.ServerName, message)
+			m := alert.Message(db, a)
+			m.Subject, m.Body, m.HTML = msg.Subject, msg.Text, msg.HTML
+			_, err := outbox.Deliver(db, m)
+			if err != nil {
+				log.Printf("Backup watchdog: failed to send alert for %s, queued for retry: %v", source.Ip, err)
+			}
+		}
+
+		db.Model(source).Updates(map[string]interface{}{
+			"last_alert_run":  run,
+			"last_alert_type": alertType,
+		})
+	}
+}
+
+// evaluateSource 判断备份来源最近一次计划运行的状态，返回告警类型、计划运行时间和告警内容
+func evaluateSource(db *gorm.DB, source *model.BackupSource, now time.Time) (string, time.Time, string) {
+	schedule, err := cron.ParseStandard(source.Schedule)
+	if err != nil {
+		log.Printf("Backup watchdog: invalid schedule %q for %s: %v", source.Schedule, source.Ip, err)
+		return "", time.Time{}, ""
+	}
+
+	grace := time.Duration(source.StartGrace) * time.Minute
+	run := LastScheduledRun(schedule, now.Add(-grace))
+	if run.IsZero() {
+		return "", run, ""
+	}
+
+	var backupLog model.BackupLog
+	err = db.Where("ip = ? AND created_at >= ?", source.Ip, run.Add(-earlyStartTolerance)).
+		Order("id DESC").First(&backupLog).Error
+	if err != nil {
+		return AlertNotStarted, run, fmt.Sprintf("计划于 %s 执行的备份未在 %d 分钟内开始",
+			run.Format("2006-01-02 15:04:05"), source.StartGrace)
+	}
+
+	maxDuration := time.Duration(source.MaxDuration) * time.Minute
+	if backupLog.EndTime.IsZero() && now.Sub(backupLog.CreatedAt) > maxDuration {
+		return AlertNotFinished, run, fmt.Sprintf("于 %s 开始的备份未在 %d 分钟内完成",
+			backupLog.CreatedAt.Format("2006-01-02 15:04:05"), source.MaxDuration)
+	}
+	return "", run, ""
+}
+
+// LastScheduledRun 返回不晚于 t 的最近一次计划运行时间，一年内没有则返回零值
+func LastScheduledRun(schedule cron.Schedule, t time.Time) time.Time {
+	for _, window := range []time.Duration{time.Hour, 24 * time.Hour, 8 * 24 * time.Hour, 32 * 24 * time.Hour, 366 * 24 * time.Hour} {
+		next := schedule.Next(t.Add(-window))
+		if next.IsZero() || next.After(t) {
+			continue
+		}
+		last := next
+		for {
+			next = schedule.Next(last)
+			if next.IsZero() || next.After(t) {
+				return last
+			}
+			last = next
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,267 @@
+package job
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+	_ "time/tzdata" // 容器镜像中可能没有时区数据库
+
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// DigestCert 汇总报告中的一个证书
+type DigestCert struct {
+	Domain     string `json:"domain"`
+	Status     string `json:"status"`
+	Issuer     string `json:"issuer"`
+	ExpiryDate string `json:"expiryDate"`
+	DaysLeft   int    `json:"daysLeft"`
+	Reason     string `json:"reason"` // 证书异常时最严重的检查发现
+}
+
+// DigestBackupStat 汇总报告中一台服务器的备份统计
+type DigestBackupStat struct {
+	Ip          string `json:"ip"`
+	ServerName  string `json:"serverName"`
+	Runs        int    `json:"runs"`
+	Finished    int    `json:"finished"`
+	Succeeded   int    `json:"succeeded"`
+	SuccessRate string `json:"successRate"` // 百分比，没有已完成的备份时为 "-"
+}
+
+// DigestMissingBackup 汇总报告中一个缺失备份的来源
+type DigestMissingBackup struct {
+	Ip         string `json:"ip"`
+	ServerName string `json:"serverName"`
+	Message    string `json:"message"`
+}
+
+// DigestReport 一次汇总报告的内容
+type DigestReport struct {
+	Name           string                `json:"name"`
+	GeneratedAt    time.Time             `json:"generatedAt"`
+	PeriodStart    time.Time             `json:"periodStart"`
+	ExpiryDays     int                   `json:"expiryDays"`
+	PeriodDays     int                   `json:"periodDays"`
+	ExpiringCerts  []DigestCert          `json:"expiringCerts"`
+	ErrorCerts     []DigestCert          `json:"errorCerts"`
+	BackupStats    []DigestBackupStat    `json:"backupStats"`
+	MissingBackups []DigestMissingBackup `json:"missingBackups"`
+}
+
+// StartDigests 为每个配置的汇总报告启动后台任务，按 cron 计划在配置的时区发送
+func StartDigests(db *gorm.DB, digests []config.DigestConfig) error {
+	for _, d := range digests {
+		schedule, loc, err := parseDigestSchedule(d)
+		if err != nil {
+			return fmt.Errorf("汇总报告 %s: %w", d.Name, err)
+		}
+		go func(d config.DigestConfig) {
+			for {
+				next := schedule.Next(time.Now().In(loc))
+				time.Sleep(time.Until(next))
+				if err := SendDigest(db, d, time.Now()); err != nil {
+					log.Printf("Digest %s: failed to send, queued for retry: %v", d.Name, err)
+				}
+			}
+		}(d)
+		log.Printf("Digest %s scheduled, schedule %q, timezone %s", d.Name, d.Schedule, loc)
+	}
+	return nil
+}
+
+func parseDigestSchedule(d config.DigestConfig) (cron.Schedule, *time.Location, error) {
+	if d.Name == "" {
+		return nil, nil, fmt.Errorf("缺少名称")
+	}
+	schedule, err := cron.ParseStandard(d.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的cron表达式: %w", err)
+	}
+	loc, err := d.Location()
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的时区: %w", err)
+	}
+	return schedule, loc, nil
+}
+
+// SendDigest 生成汇总报告并通过发件箱发送，邮件附带 CSV 格式的明细
+func SendDigest(db *gorm.DB, d config.DigestConfig, now time.Time) error {
+	report, err := BuildDigest(db, d, now)
+	if err != nil {
+		return err
+	}
+	msg := report.Message(db)
+	_, err = outbox.Deliver(db, outbox.Message{
+		Kind:        "digest",
+		Severity:    model.SeverityInfo,
+		To:          d.Recipients,
+		Subject:     msg.Subject,
+		Body:        msg.Text,
+		HTML:        msg.HTML,
+		Attachments: msg.Attachments,
+	})
+	return err
+}
+
+// BuildDigest 统计即将过期和异常的证书、各服务器的备份成功率以及缺失的备份
+func BuildDigest(db *gorm.DB, d config.DigestConfig, now time.Time) (*DigestReport, error) {
+	loc, err := d.Location()
+	if err != nil {
+		return nil, err
+	}
+	now = now.In(loc)
+	report := &DigestReport{
+		Name:           d.Name,
+		GeneratedAt:    now,
+		PeriodStart:    now.AddDate(0, 0, -d.Period()),
+		ExpiryDays:     d.ExpiryWindow(),
+		PeriodDays:     d.Period(),
+		ExpiringCerts:  []DigestCert{},
+		ErrorCerts:     []DigestCert{},
+		BackupStats:    []DigestBackupStat{},
+		MissingBackups: []DigestMissingBackup{},
+	}
+
+	var expiring []model.Domain
+	err = db.Where("certificate_expiry_date > ? AND certificate_expiry_date <= ?", now, now.AddDate(0, 0, report.ExpiryDays)).
+		Order("certificate_expiry_date ASC").Find(&expiring).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取即将过期的证书失败: %w", err)
+	}
+	for i := range expiring {
+		report.ExpiringCerts = append(report.ExpiringCerts, digestCert(&expiring[i], now, ""))
+	}
+
+	var failing []model.Domain
+	if err := db.Where("certificate_status = ?", "ERROR").Order("domain_name ASC").Find(&failing).Error; err != nil {
+		return nil, fmt.Errorf("获取异常证书失败: %w", err)
+	}
+	for i := range failing {
+		var finding model.DomainFinding
+		reason := ""
+		if db.Where("domain_id = ? AND severity = ?", failing[i].ID, model.SeverityCritical).
+			Order("id ASC").First(&finding).Error == nil {
+			reason = finding.Message
+		}
+		report.ErrorCerts = append(report.ErrorCerts, digestCert(&failing[i], now, reason))
+	}
+
+	var logs []model.BackupLog
+	if err := db.Where("start_time >= ?", report.PeriodStart).Order("start_time ASC").Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("获取备份日志失败: %w", err)
+	}
+	byServer := make(map[string]*DigestBackupStat)
+	for _, l := range logs {
+		stats, ok := byServer[l.Ip]
+		if !ok {
+			stats = &DigestBackupStat{Ip: l.Ip}
+			byServer[l.Ip] = stats
+		}
+		stats.ServerName = l.ServerName
+		stats.Runs++
+		if l.EndTime.IsZero() {
+			continue
+		}
+		stats.Finished++
+		if l.BackupStatus == 0 {
+			stats.Succeeded++
+		}
+	}
+	for _, stats := range byServer {
+		stats.SuccessRate = "-"
+		if stats.Finished > 0 {
+			stats.SuccessRate = fmt.Sprintf("%.1f%%", float64(stats.Succeeded)*100/float64(stats.Finished))
+		}
+		report.BackupStats = append(report.BackupStats, *stats)
+	}
+	sort.Slice(report.BackupStats, func(i, j int) bool { return report.BackupStats[i].Ip < report.BackupStats[j].Ip })
+
+	var sources []model.BackupSource
+	if err := db.Where("enabled = ?", true).Order("ip ASC").Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("获取备份来源失败: %w", err)
+	}
+	for i := range sources {
+		if alertType, _, message := evaluateSource(db, &sources[i], now); alertType != "" {
+			report.MissingBackups = append(report.MissingBackups, DigestMissingBackup{
+				Ip:         sources[i].Ip,
+				ServerName: sources[i].ServerName,
+				Message:    message,
+			})
+		}
+	}
+	return report, nil
+}
+
+func digestCert(domain *model.Domain, now time.Time, reason string) DigestCert {
+	cert := DigestCert{
+		Domain: domain.DomainName,
+		Status: domain.CertificateStatus,
+		Issuer: domain.CertificateIssuer,
+		Reason: reason,
+	}
+	if !domain.CertificateExpiryDate.IsZero() {
+		cert.ExpiryDate = domain.CertificateExpiryDate.In(now.Location()).Format("2006-01-02 15:04:05")
+		cert.DaysLeft = int(domain.CertificateExpiryDate.Sub(now).Hours() / 24)
+	}
+	return cert
+}
+
+// TemplateData 返回渲染 digest 模板使用的数据
+func (r *DigestReport) TemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":           r.Name,
+		"GeneratedAt":    r.GeneratedAt.Format("2006-01-02 15:04:05 MST"),
+		"PeriodStart":    r.PeriodStart.Format("2006-01-02 15:04"),
+		"PeriodEnd":      r.GeneratedAt.Format("2006-01-02 15:04"),
+		"ExpiryDays":     r.ExpiryDays,
+		"PeriodDays":     r.PeriodDays,
+		"ExpiringCerts":  r.ExpiringCerts,
+		"ErrorCerts":     r.ErrorCerts,
+		"BackupStats":    r.BackupStats,
+		"MissingBackups": r.MissingBackups,
+	}
+}
+
+// Message 渲染报告邮件并附带 CSV 明细
+func (r *DigestReport) Message(db *gorm.DB) email.Message {
+	msg := email.DigestMessage(db, r.TemplateData())
+	msg.Attachments = []email.Attachment{{
+		Filename:    fmt.Sprintf("digest-%s-%s.csv", r.Name, r.GeneratedAt.Format("20060102")),
+		ContentType: "text/csv; charset=UTF-8",
+		Data:        r.CSV(),
+	}}
+	return msg
+}
+
+// CSV 以一张表导出报告明细，第一列为所属部分；带 UTF-8 BOM 以便 Excel 正确识别中文
+func (r *DigestReport) CSV() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"section", "name", "address", "status", "expiry_date", "days_left", "runs", "finished", "succeeded", "success_rate", "detail"})
+	for _, c := range r.ExpiringCerts {
+		w.Write([]string{"expiring_certificate", c.Domain, "", c.Status, c.ExpiryDate, strconv.Itoa(c.DaysLeft), "", "", "", "", c.Issuer})
+	}
+	for _, c := range r.ErrorCerts {
+		w.Write([]string{"certificate_error", c.Domain, "", c.Status, c.ExpiryDate, strconv.Itoa(c.DaysLeft), "", "", "", "", c.Reason})
+	}
+	for _, s := range r.BackupStats {
+		w.Write([]string{"backup_success_rate", s.ServerName, s.Ip, "", "", "",
+			strconv.Itoa(s.Runs), strconv.Itoa(s.Finished), strconv.Itoa(s.Succeeded), s.SuccessRate, ""})
+	}
+	for _, m := range r.MissingBackups {
+		w.Write([]string{"missing_backup", m.ServerName, m.Ip, "", "", "", "", "", "", "", m.Message})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
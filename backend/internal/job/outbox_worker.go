@@ -0,0 +1,21 @@
+package job
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// StartOutboxWorker 启动后台任务，定期重试发件箱中发送失败的通知
+func StartOutboxWorker(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			outbox.ProcessDue(db)
+		}
+	}()
+	log.Printf("Outbox worker started, interval %s", interval)
+}
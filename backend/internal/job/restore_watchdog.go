@@ -0,0 +1,75 @@
+package job
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/alert"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// CheckRestoreVerification 检查启用的备份来源最近是否有成功的恢复验证，超过允许天数时告警，
+// 之后每隔同样的天数重复提醒，直到有新的成功验证。defaultMaxAge 为全局配置的天数，0 表示不检查
+func CheckRestoreVerification(db *gorm.DB, now time.Time, defaultMaxAge int) {
+	var sources []model.BackupSource
+	if err := db.Where("enabled = ?", true).Find(&sources).Error; err != nil {
+		log.Printf("Restore watchdog: failed to load sources: %v", err)
+		return
+	}
+
+	for i := range sources {
+		source := &sources[i]
+		maxAgeDays := source.RestoreMaxAge
+		if maxAgeDays <= 0 {
+			maxAgeDays = defaultMaxAge
+		}
+		if maxAgeDays <= 0 {
+			continue
+		}
+		maxAge := time.Duration(maxAgeDays) * 24 * time.Hour
+
+		// 从未验证过的来源从登记时开始计算
+		since := source.CreatedAt
+		var last model.RestoreTest
+		err := db.Where("ip = ? AND result = 0", source.Ip).Order("tested_at DESC").First(&last).Error
+		if err == nil {
+			since = last.TestedAt
+		}
+		if now.Sub(since) < maxAge {
+			if !source.LastRestoreAlertAt.IsZero() {
+				alert.Resolve(db, alert.RestoreMissingKey(source.Ip), now)
+			}
+			continue
+		}
+		if now.Sub(source.LastRestoreAlertAt) < maxAge {
+			continue
+		}
+
+		message := fmt.Sprintf("已超过 %d 天没有成功的备份恢复验证", maxAgeDays)
+		if err == nil {
+			message += fmt.Sprintf("（最近一次成功验证于 %s）", last.TestedAt.Format("2006-01-02 15:04:05"))
+		}
+		target := alert.ServerTarget(db, source.Ip)
+		a, notify := alert.Raise(db, alert.Condition{
+			Key:      alert.RestoreMissingKey(source.Ip),
+			Kind:     "restore_missing",
+			Severity: model.SeverityWarning,
+			Message:  message,
+			Target:   target,
+		}, now)
+		if notify {
+			msg := email.BackupAlertMessage(db, source.Ip, source.ServerName, message)
+			m := alert.Message(db, a)
+			m.Subject, m.Body, m.HTML = msg.Subject, msg.Text, msg.HTML
+			_, err := outbox.Deliver(db, m)
+			if err != nil {
+				log.Printf("Restore watchdog: failed to send alert for %s, queued for retry: %v", source.Ip, err)
+			}
+		}
+		db.Model(source).Update("last_restore_alert_at", now)
+	}
+}
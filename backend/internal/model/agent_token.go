@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// AgentToken 备份脚本使用的长期摄取令牌，只保存令牌的 SHA-256 哈希
+type AgentToken struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name"`
+	Ip          string    `json:"ip" gorm:"size:64;index;not null"` // 令牌所属服务器的地址，备份日志归属于该地址
+	ServerName  string    `json:"serverName"`
+	SourceIP    string    `json:"sourceIp" gorm:"size:64"` // 非空时只接受来自该地址的请求
+	TokenHash   string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	TokenPrefix string    `json:"tokenPrefix" gorm:"size:16"` // 令牌前缀，便于识别
+	Revoked     bool      `json:"revoked" gorm:"default:false"`
+	RevokedAt   time.Time `json:"revokedAt"`
+	LastUsedAt  time.Time `json:"lastUsedAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// 告警状态
+const (
+	AlertOpen         = "open"         // 未处理
+	AlertAcknowledged = "acknowledged" // 已确认，不再通知，直到解决
+	AlertSnoozed      = "snoozed"      // 暂停通知，直到 SnoozedUntil
+	AlertResolved     = "resolved"     // 已解决
+)
+
+// 告警事件类型
+const (
+	AlertEventOpened       = "opened"
+	AlertEventAcknowledged = "acknowledged"
+	AlertEventSnoozed      = "snoozed"
+	AlertEventReopened     = "reopened"   // 暂停到期后再次触发
+	AlertEventSuppressed   = "suppressed" // 处于维护窗口，未发送通知
+	AlertEventResolved     = "resolved"
+)
+
+// Alert 一个持续存在的告警条件（证书或备份），同一 Key 同时只有一条未解决的告警
+type Alert struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	Key            string       `json:"key" gorm:"size:191;index;not null"` // 告警条件标识，如 backup:10.0.0.1、pin:3
+	Kind           string       `json:"kind" gorm:"size:32;index"`          // 通知类型，如 backup_alert、backup_missing、domain_alert
+	Severity       string       `json:"severity" gorm:"size:16"`
+	DomainID       uint         `json:"domainId" gorm:"index"`
+	ServerID       uint         `json:"serverId" gorm:"index"`
+	Ip             string       `json:"ip" gorm:"size:64;index"`
+	Message        string       `json:"message" gorm:"type:text"`
+	Status         string       `json:"status" gorm:"size:16;index"`
+	SnoozedUntil   time.Time    `json:"snoozedUntil"`
+	AcknowledgedBy string       `json:"acknowledgedBy" gorm:"size:64"`
+	AcknowledgedAt time.Time    `json:"acknowledgedAt"`
+	Occurrences    int          `json:"occurrences"` // 告警条件出现的次数
+	LastSeenAt     time.Time    `json:"lastSeenAt"`  // 最近一次出现的时间
+	LastNotifiedAt time.Time    `json:"lastNotifiedAt"`
+	ResolvedAt     time.Time    `json:"resolvedAt"`
+	Events         []AlertEvent `json:"events,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+// AlertEvent 告警的状态变化及处理备注
+type AlertEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	AlertID   uint      `json:"alertId" gorm:"index;not null"`
+	Action    string    `json:"action" gorm:"size:32"`
+	User      string    `json:"user" gorm:"size:64"` // 操作的用户，系统自动处理时为空
+	Comment   string    `json:"comment" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MaintenanceWindow 域名或服务器的计划维护时间，期间检查照常进行但不发送告警通知
+type MaintenanceWindow struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DomainID  uint      `json:"domainId" gorm:"index"` // 与 ServerID 二选一
+	ServerID  uint      `json:"serverId" gorm:"index"`
+	StartsAt  time.Time `json:"startsAt" gorm:"index;not null"`
+	EndsAt    time.Time `json:"endsAt" gorm:"index;not null"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"createdBy" gorm:"size:64"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// BackupLog.AlertStatus 的取值，反映告警策略实际执行的结果
+const (
+	AlertStatusNone       = 0 // 正常，无需告警
+	AlertStatusSent       = 1 // 告警已发送
+	AlertStatusFailed     = 2 // 告警发送失败
+	AlertStatusSuppressed = 3 // 失败但未达到告警阈值或已告警过，未重复发送
+	AlertStatusEscalated  = 4 // 已升级告警
+	AlertStatusRecovered  = 5 // 已发送恢复通知
+	AlertStatusSilenced   = 6 // 告警已确认、暂停通知或处于维护窗口，未发送
+)
+
+// 告警级别
+const (
+	AlertLevelNone      = 0
+	AlertLevelAlerted   = 1
+	AlertLevelEscalated = 2
+)
+
+// BackupAlertState 每台服务器的告警状态，用于连续失败计数、升级和恢复通知
+type BackupAlertState struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	Ip                  string    `json:"ip" gorm:"size:64;uniqueIndex;not null"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	Level               int       `json:"level"` // 当前已发送的告警级别
+	LastFailureLogID    uint      `json:"lastFailureLogId"`
+	LastNotifiedAt      time.Time `json:"lastNotifiedAt"`
+	CreatedAt           time.Time `json:"createdAt"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
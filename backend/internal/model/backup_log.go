@@ -2,22 +2,67 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// BackupLog 备份脚本上报的一次备份运行记录
 type BackupLog struct {
-	Id            uint   `json:"id" gorm:"column:Id;primaryKey"`
-	Ip            string `json:"ip" gorm:"column:Ip;not null"`
-	ServerName    string `json:"server_name" gorm:"column:ServerName;not null"`
-	StartTime     string `json:"start_time" gorm:"column:StartTime;not null;default:''"`
-	EndTime       string `json:"end_time" gorm:"column:EndTime;default:''"`
-	BackupStatus  int    `json:"backup_status" gorm:"column:BackupStatus;not null;default:0"`
-	AlertStatus   int    `json:"alert_status" gorm:"column:AlertStatus;not null;default:0"`
-	ScriptVersion string `json:"script_version" gorm:"column:ScriptVersion;not null;default:'0'"`
-	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"column:updated_at"`
+	Id              uint       `json:"id" gorm:"primaryKey"`
+	ServerID        uint       `json:"server_id" gorm:"index"`            // 关联的服务器，地址未登记时为 0
+	Ip              string     `json:"ip" gorm:"size:64;index;not null"`  // 规范化后的地址，支持 IPv6
+	IpBin           []byte     `json:"-" gorm:"type:varbinary(16);index"` // 地址的 16 字节表示，用于按网段查询
+	ServerName      string     `json:"server_name" gorm:"size:100;not null"`
+	StartTime       BackupTime `json:"start_time" gorm:"index"`
+	EndTime         BackupTime `json:"end_time"`
+	DurationSeconds int64      `json:"duration_seconds"` // 由开始和结束时间计算的耗时（秒）
+	BackupStatus    int        `json:"backup_status" gorm:"type:tinyint;not null;default:0"`
+	AlertStatus     int        `json:"alert_status" gorm:"type:tinyint;not null;default:0"` // 0:正常,1:告警已触发,2:告警未触发
+	ScriptVersion   string     `json:"script_version" gorm:"not null;default:'0'"`
+	ExitCode        *int       `json:"exit_code"`                      // 备份脚本退出码，未上报时为 null
+	BytesWritten    int64      `json:"bytes_written"`                  // 写入备份目标的字节数
+	Checksum        string     `json:"checksum" gorm:"size:128"`       // 备份目标文件的校验和
+	ErrorExcerpt    string     `json:"error_excerpt" gorm:"type:text"` // 脚本输出的末尾几行，用于告警邮件
+	HasOutput       bool       `json:"has_output"`                     // 是否保存了脚本输出，见 BackupLogOutput
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // TableName 指定表名
 func (BackupLog) TableName() string {
-	return "backuplogs"
-} 
\ No newline at end of file
+	return "backup_logs"
+}
+
+// ComputeDuration 根据开始和结束时间计算耗时，任一时间缺失时为 0
+func (l *BackupLog) ComputeDuration() {
+	l.DurationSeconds = 0
+	if !l.StartTime.IsZero() && !l.EndTime.IsZero() && l.EndTime.After(l.StartTime.Time) {
+		l.DurationSeconds = int64(l.EndTime.Sub(l.StartTime.Time).Seconds())
+	}
+}
+
+// BeforeSave - GORM hook，保存前计算耗时及地址的二进制表示
+func (l *BackupLog) BeforeSave(tx *gorm.DB) error {
+	l.ComputeDuration()
+	if l.Ip != "" {
+		l.IpBin = IPBytes(l.Ip)
+	}
+	return nil
+}
+
+// DeleteBackupLogs 删除备份日志及其保存的脚本输出，应在事务中调用。
+// 恢复验证、发件箱消息和通知发送记录另有地址和服务器信息，保留下来但不再关联已删除的日志
+func DeleteBackupLogs(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, dependent := range []interface{}{&RestoreTest{}, &OutboxMessage{}, &NotificationAttempt{}} {
+		if err := tx.Model(dependent).Where("backup_log_id IN ?", ids).Update("backup_log_id", 0).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("backup_log_id IN ?", ids).Delete(&BackupLogOutput{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", ids).Delete(&BackupLog{}).Error
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// BackupLogOutput 备份脚本输出，gzip 压缩保存，超过大小上限时只保留末尾部分
+type BackupLogOutput struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	BackupLogID  uint      `json:"backupLogId" gorm:"uniqueIndex;not null"`
+	Content      []byte    `json:"-" gorm:"type:mediumblob"`
+	OriginalSize int64     `json:"originalSize"` // 上报的输出大小（字节）
+	StoredSize   int64     `json:"storedSize"`   // 截断后保存的输出大小（字节）
+	Truncated    bool      `json:"truncated"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
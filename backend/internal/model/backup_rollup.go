@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// BackupDailyRollup 每台服务器每天的备份汇总，原始备份日志超过保留期删除后仍保留
+type BackupDailyRollup struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	Day                  time.Time `json:"day" gorm:"type:date;uniqueIndex:idx_backup_rollup_day_ip;not null"`
+	Ip                   string    `json:"ip" gorm:"size:64;uniqueIndex:idx_backup_rollup_day_ip;not null"`
+	ServerID             uint      `json:"serverId" gorm:"index"`
+	ServerName           string    `json:"serverName" gorm:"size:100"`
+	Runs                 int       `json:"runs"`
+	Successes            int       `json:"successes"`
+	Failures             int       `json:"failures"`
+	Unfinished           int       `json:"unfinished"` // 没有结束时间的运行
+	TotalDurationSeconds int64     `json:"totalDurationSeconds"`
+	MaxDurationSeconds   int64     `json:"maxDurationSeconds"`
+	CreatedAt            time.Time `json:"createdAt"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
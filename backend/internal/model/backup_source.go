@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// BackupSource 预期会上报备份日志的服务器及其备份计划
+type BackupSource struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Ip            string    `json:"ip" gorm:"uniqueIndex;size:64;not null"`
+	ServerName    string    `json:"serverName"`
+	Schedule      string    `json:"schedule" gorm:"not null"`       // 标准 cron 表达式，如 "0 2 * * *"
+	StartGrace    int       `json:"startGrace" gorm:"default:30"`   // 计划时间后允许开始的宽限期（分钟）
+	MaxDuration   int       `json:"maxDuration" gorm:"default:240"` // 开始后必须完成的时间（分钟）
+	Enabled       bool      `json:"enabled"`
+	LastAlertRun  time.Time `json:"lastAlertRun"`  // 最近一次已告警的计划运行时间
+	LastAlertType string    `json:"lastAlertType"` // 最近一次告警类型: not_started / not_finished
+
+	RestoreMaxAge      int       `json:"restoreMaxAge"`      // 允许多少天没有成功的恢复验证，0 表示使用全局配置
+	LastRestoreAlertAt time.Time `json:"lastRestoreAlertAt"` // 最近一次恢复验证缺失告警的时间
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
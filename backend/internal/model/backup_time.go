@@ -0,0 +1,117 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupTimeLayout 备份时间在 JSON 中的输出格式，与脚本上报格式一致
+const BackupTimeLayout = "2006-01-02 15:04:05"
+
+// backupTimeLayouts 脚本可能上报的时间格式
+var backupTimeLayouts = []string{
+	BackupTimeLayout,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04",
+	"20060102150405",
+	"20060102 15:04:05",
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+}
+
+// BackupTime 备份开始/结束时间，零值表示未设置并以 NULL 存储
+type BackupTime struct {
+	time.Time
+}
+
+// ParseBackupTime 解析脚本上报的时间字符串，无时区信息时按本地时间解析，也支持 Unix 时间戳
+func ParseBackupTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) == 10 {
+		return time.Unix(sec, 0), nil
+	}
+	for _, layout := range backupTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析的时间: %q", s)
+}
+
+func (t BackupTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.Local().Format(BackupTimeLayout))
+}
+
+func (t *BackupTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// 兼容直接上报数字时间戳
+		var sec int64
+		if err := json.Unmarshal(data, &sec); err != nil {
+			return err
+		}
+		t.Time = time.Unix(sec, 0)
+		return nil
+	}
+	parsed, err := ParseBackupTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Value 实现 driver.Valuer，零值写入 NULL
+func (t BackupTime) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+// Scan 实现 sql.Scanner
+func (t *BackupTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	case []byte:
+		parsed, err := ParseBackupTime(string(v))
+		if err != nil {
+			return err
+		}
+		t.Time = parsed
+	case string:
+		parsed, err := ParseBackupTime(v)
+		if err != nil {
+			return err
+		}
+		t.Time = parsed
+	default:
+		return fmt.Errorf("无法转换为 BackupTime: %T", value)
+	}
+	return nil
+}
+
+// GormDataType 指定数据库列类型
+func (BackupTime) GormDataType() string {
+	return "time"
+}
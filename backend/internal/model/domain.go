@@ -5,12 +5,20 @@ import "time"
 type Domain struct {
 	ID                  uint      `json:"id" gorm:"primaryKey"`
 	DomainName         string    `json:"domainName" gorm:"unique;not null"`
-	NotificationEmail   string    `json:"notificationEmail"`
+	NotificationEmail   string    `json:"notificationEmail" gorm:"type:text"` // 通知邮箱，多个地址以逗号分隔
+	Tags               string    `json:"tags"`                              // 逗号分隔，用于通知路由
+	Environment        string    `json:"environment" gorm:"size:32;index"` // 如 prod、staging、dev
+	OwnerTeam          string    `json:"ownerTeam" gorm:"size:64"`          // 负责团队，用于通知路由
 	CertificateStatus  string    `json:"certificateStatus"`
 	CertificateIssuer  string    `json:"certificateIssuer"`
 	CertificateExpiryDate time.Time `json:"certificateExpiryDate"`
+	CertificateFingerprint string `json:"certificateFingerprint" gorm:"size:64;index"` // 叶子证书 SHA-256 指纹
+	CertificateSANs    string    `json:"certificateSans" gorm:"type:text"` // 证书 SAN 列表，逗号分隔
 	LastChecked        time.Time `json:"lastChecked"`
 	AutoRenewal        bool      `json:"autoRenewal" gorm:"default:true"`
+	TLSAPorts          string    `json:"tlsaPorts"` // 需要进行 DANE/TLSA 校验的端口，逗号分隔，如 "443,25"
+	HTTPCheckEnabled   bool      `json:"httpCheckEnabled"`
+	HTTPCheckPath      string    `json:"httpCheckPath"` // HTTP 检查请求的路径，默认为 "/"
 	CreatedAt          time.Time `json:"createdAt"`
 	UpdatedAt          time.Time `json:"updatedAt"`
 } 
\ No newline at end of file
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// 检查发现的严重级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// DomainFinding 域名检查过程中发现的问题
+type DomainFinding struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DomainID  uint      `json:"domainId" gorm:"index;not null"`
+	Check     string    `json:"check" gorm:"size:32;index;not null"`
+	Severity  string    `json:"severity" gorm:"size:16;not null"`
+	Message   string    `json:"message" gorm:"size:1024"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// HTTPCheck 域名最近一次 HTTP 阶段检查的结果
+type HTTPCheck struct {
+	ID                    uint      `json:"id" gorm:"primaryKey"`
+	DomainID              uint      `json:"domainId" gorm:"uniqueIndex;not null"`
+	URL                   string    `json:"url"`
+	StatusCode            int       `json:"statusCode"`
+	HSTSPresent           bool      `json:"hstsPresent"`
+	HSTSMaxAge            int64     `json:"hstsMaxAge"`
+	HSTSIncludeSubDomains bool      `json:"hstsIncludeSubDomains"`
+	HSTSPreload           bool      `json:"hstsPreload"`
+	RedirectsToHTTPS      bool      `json:"redirectsToHttps"`
+	RedirectStatus        int       `json:"redirectStatus"`
+	RedirectLocation      string    `json:"redirectLocation"`
+	Headers               string    `json:"headers" gorm:"type:text"` // 安全相关响应头，JSON 格式
+	CheckedAt             time.Time `json:"checkedAt"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
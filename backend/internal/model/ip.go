@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// NormalizeIP 校验并规范化地址：IPv6 使用压缩小写形式，IPv4 映射地址转换为 IPv4
+func NormalizeIP(s string) (string, error) {
+	s = strings.Trim(strings.TrimSpace(s), "[]")
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", fmt.Errorf("无效的IP地址: %q", s)
+	}
+	return addr.WithZone("").Unmap().String(), nil
+}
+
+// IPBytes 返回地址的 16 字节表示（IPv4 使用映射形式），用于按网段查询，无效地址返回 nil
+func IPBytes(ip string) []byte {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	b := addr.WithZone("").As16()
+	return b[:]
+}
+
+// IPRange 返回网段（如 10.2.0.0/16 或 2001:db8::/32）的首末地址的 16 字节表示
+func IPRange(cidr string) ([]byte, []byte, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的网段: %q", cidr)
+	}
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		bits += 96
+	}
+	lo := prefix.Masked().Addr().As16()
+	hi := lo
+	for i := bits; i < 128; i++ {
+		hi[i/8] |= 1 << (7 - uint(i%8))
+	}
+	return lo[:], hi[:], nil
+}
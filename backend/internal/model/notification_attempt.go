@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 通知发送结果
+const (
+	AttemptSent    = "sent"
+	AttemptFailed  = "failed"
+	AttemptSkipped = "skipped" // 渠道未启用
+)
+
+// NotificationAttempt 一次通知发送的记录，每次发送、重试、手动重发和测试各记录一条，用于审计
+type NotificationAttempt struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	OutboxMessageID uint      `json:"outboxMessageId" gorm:"index"` // 发件箱消息，测试通知为 0
+	Kind            string    `json:"kind" gorm:"size:32;index"`    // 通知类型，如 backup_alert、domain_alert、digest、test
+	Action          string    `json:"action" gorm:"size:16"`        // 事件操作：trigger（空）、acknowledge、resolve
+	Channel         string    `json:"channel" gorm:"size:64;index"`
+	ChannelType     string    `json:"channelType" gorm:"size:32"`
+	Recipients      string    `json:"recipients" gorm:"type:text"` // 实际的邮件收件人，逗号分隔，其他渠道为空
+	Subject         string    `json:"subject"`
+	Attempt         int       `json:"attempt"` // 该消息的第几次发送
+	Status          string    `json:"status" gorm:"size:16;index"`
+	Response        string    `json:"response" gorm:"type:text"` // 渠道的应答，如 SMTP 服务器的应答、HTTP 响应内容
+	Error           string    `json:"error" gorm:"type:text"`
+	StartedAt       time.Time `json:"startedAt" gorm:"index"`
+	DurationMs      int64     `json:"durationMs"`
+	AlertID         uint      `json:"alertId" gorm:"index"` // 关联的告警、域名、服务器和备份日志，可为 0
+	DomainID        uint      `json:"domainId" gorm:"index"`
+	ServerID        uint      `json:"serverId" gorm:"index"`
+	Ip              string    `json:"ip" gorm:"size:64;index"`
+	BackupLogID     uint      `json:"backupLogId" gorm:"index"`
+	User            string    `json:"user" gorm:"size:64"` // 手动重发或测试的用户，自动发送时为空
+	CreatedAt       time.Time `json:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// NotificationTemplate 用户编辑的通知模板，优先于模板目录和内置模板
+type NotificationTemplate struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:64;uniqueIndex:idx_template_name_lang;not null"` // 模板名称，如 backup_alert
+	Lang      string    `json:"lang" gorm:"size:8;uniqueIndex:idx_template_name_lang;not null"`  // zh 或 en
+	Subject   string    `json:"subject" gorm:"type:text"`
+	Text      string    `json:"text" gorm:"type:text"`
+	HTML      string    `json:"html" gorm:"type:mediumtext"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// 值班交接周期
+const (
+	HandoverDaily  = "daily"
+	HandoverWeekly = "weekly"
+)
+
+// OnCallRotation 值班轮换：成员按顺序轮流值班，从 StartsAt 开始每天或每周在同一时刻交接
+type OnCallRotation struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	Name      string           `json:"name" gorm:"size:64;uniqueIndex;not null"` // 路由规则的 rotations 按名称引用
+	Members   string           `json:"-" gorm:"type:text"`                       // 值班成员邮箱，按值班顺序以逗号分隔
+	Handover  string           `json:"handover" gorm:"size:16"`                  // daily 或 weekly
+	StartsAt  time.Time        `json:"startsAt"`                                 // 第一位成员开始值班的时间
+	Timezone  string           `json:"timezone" gorm:"size:64"`                  // 计算交接时刻的时区，为空时使用服务器时区
+	Overrides []OnCallOverride `json:"overrides,omitempty" gorm:"foreignKey:RotationID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// OnCallOverride 临时替班，期间由 Email 代替轮换中的成员值班
+type OnCallOverride struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	RotationID uint      `json:"rotationId" gorm:"index;not null"`
+	Email      string    `json:"email" gorm:"size:255"`
+	StartsAt   time.Time `json:"startsAt" gorm:"index"`
+	EndsAt     time.Time `json:"endsAt" gorm:"index"`
+	Reason     string    `json:"reason"`
+	CreatedBy  string    `json:"createdBy" gorm:"size:64"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// 发件箱消息状态
+const (
+	OutboxPending = "pending" // 等待发送或重试
+	OutboxSending = "sending" // 已被领取，正在发送；领取超时（进程在发送中退出）后由后台任务重新领取
+	OutboxSent    = "sent"    // 已发送
+	OutboxDead    = "dead"    // 超过重试次数，不再自动重试
+	OutboxSkipped = "skipped" // 渠道未启用（如 email.enabled 为 false），不发送也不重试
+)
+
+// OutboxMessage 发件箱中的一条待发送通知
+type OutboxMessage struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Kind            string    `json:"kind" gorm:"size:32;index"`              // 通知类型，如 backup_alert、backup_recovery、domain_alert
+	Channel         string    `json:"channel" gorm:"size:64;default:'email'"` // 通知渠道名称，见 notifications.channels
+	Recipients      string    `json:"recipients" gorm:"type:text"`            // 逗号分隔，为空时使用默认收件人
+	Subject         string    `json:"subject"`
+	Body            string    `json:"body" gorm:"type:text"`
+	HTMLBody        string    `json:"htmlBody" gorm:"type:mediumtext"` // 邮件的 HTML 部分，可为空
+	Attachments     string    `json:"-" gorm:"type:mediumtext"`        // 邮件附件（JSON），可为空
+	Severity        string    `json:"severity" gorm:"size:16"`
+	DedupKey        string    `json:"dedupKey" gorm:"size:191;index"` // 告警的去重键，PagerDuty、Opsgenie 据此合并和关闭事件
+	Action          string    `json:"action" gorm:"size:16"`          // 事件操作：trigger（空）、acknowledge、resolve
+	Status          string    `json:"status" gorm:"size:16;index"`
+	Attempts        int       `json:"attempts"`
+	NextAttemptAt   time.Time `json:"nextAttemptAt" gorm:"index"` // 下次发送时间，发送中的消息为领取的过期时间
+	LastError       string    `json:"lastError" gorm:"type:text"`
+	SentAt          time.Time `json:"sentAt"`
+	AlertID         uint      `json:"alertId" gorm:"index"` // 关联的告警、域名和服务器，可为 0
+	DomainID        uint      `json:"domainId" gorm:"index"`
+	ServerID        uint      `json:"serverId" gorm:"index"`
+	Ip              string    `json:"ip" gorm:"size:64"`
+	BackupLogID     uint      `json:"backupLogId" gorm:"index"` // 关联的备份日志，发送成功后更新其告警状态
+	SentAlertStatus int       `json:"sentAlertStatus"`          // 发送成功后写入备份日志的告警状态
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// DomainPin 域名期望的 SPKI pin (SHA-256，base64 编码)
+type DomainPin struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DomainID  uint      `json:"domainId" gorm:"uniqueIndex:idx_domain_pin;not null"`
+	Pin       string    `json:"pin" gorm:"size:64;uniqueIndex:idx_domain_pin;not null"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// RestoreTest 一次备份恢复验证的结果
+type RestoreTest struct {
+	ID                     uint      `json:"id" gorm:"primaryKey"`
+	BackupLogID            uint      `json:"backupLogId" gorm:"index;not null"`
+	Ip                     string    `json:"ip" gorm:"size:64;index;not null"` // 备份日志所属服务器的地址
+	ServerID               uint      `json:"serverId" gorm:"index"`
+	VerifiedBy             string    `json:"verifiedBy" gorm:"size:100"` // 执行验证的人或脚本
+	Target                 string    `json:"target"`                     // 验证内容，如恢复到的主机、数据库或文件
+	Checksum               string    `json:"checksum" gorm:"size:128"`   // 恢复后文件的校验和
+	ChecksumMatch          *bool     `json:"checksumMatch"`              // 与备份日志记录的校验和是否一致，无法比较时为 null
+	RestoreDurationSeconds int64     `json:"restoreDurationSeconds"`
+	Result                 int       `json:"result" gorm:"type:tinyint;not null;default:0"` // 0:成功,其他:失败
+	Message                string    `json:"message" gorm:"type:text"`
+	TestedAt               time.Time `json:"testedAt" gorm:"index"`
+	CreatedAt              time.Time `json:"createdAt"`
+}
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// SchemaMigration 记录已执行的数据迁移
+type SchemaMigration struct {
+	Name      string `gorm:"primaryKey;size:128"`
+	AppliedAt time.Time
+}
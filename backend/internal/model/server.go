@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Server 备份服务器资产信息
+type Server struct {
+	ID                    uint            `json:"id" gorm:"primaryKey"`
+	Hostname              string          `json:"hostname" gorm:"uniqueIndex;size:255;not null"`
+	Owner                 string          `json:"owner"`
+	Environment           string          `json:"environment" gorm:"size:32;index"` // 如 prod、staging、dev
+	Tags                  string          `json:"tags"`                             // 逗号分隔
+	ExpectedScriptVersion string          `json:"expectedScriptVersion"`
+	Addresses             []ServerAddress `json:"addresses" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt             time.Time       `json:"createdAt"`
+	UpdatedAt             time.Time       `json:"updatedAt"`
+}
+
+// ServerAddress 服务器的一个地址，一台服务器可以有多个地址
+type ServerAddress struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ServerID  uint      `json:"serverId" gorm:"index;not null"`
+	Ip        string    `json:"ip" gorm:"uniqueIndex;size:64;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+}
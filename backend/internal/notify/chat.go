@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// chatNotifier 发送到 Slack、Microsoft Teams、钉钉、企业微信、飞书的机器人 webhook
+type chatNotifier struct {
+	name   string
+	kind   string
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (c *chatNotifier) Name() string { return c.name }
+
+func (c *chatNotifier) Type() string { return c.kind }
+
+func (c *chatNotifier) Send(n Notification) (string, error) {
+	switch c.kind {
+	case "slack":
+		body, err := postJSON(c.client, c.url, map[string]string{"text": n.Text()})
+		return string(body), err
+	case "teams":
+		body, err := postJSON(c.client, c.url, map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  n.Subject,
+			"title":    n.Subject,
+			"text":     strings.ReplaceAll(n.Body, "\n", "  \n"),
+		})
+		return string(body), err
+	case "dingtalk":
+		target := c.url
+		if c.secret != "" {
+			// 加签：HMAC-SHA256(secret, timestamp + "\n" + secret)，时间戳为毫秒
+			ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			sign := url.QueryEscape(hmacBase64(c.secret, ts+"\n"+c.secret))
+			target += separator(target) + "timestamp=" + ts + "&sign=" + sign
+		}
+		return checkErrcode(postJSON(c.client, target, map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": n.Text()},
+		}))
+	case "wecom":
+		return checkErrcode(postJSON(c.client, c.url, map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": n.Text()},
+		}))
+	case "feishu":
+		payload := map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": n.Text()},
+		}
+		if c.secret != "" {
+			// 加签：以 timestamp + "\n" + secret 为密钥对空字符串做 HMAC-SHA256，时间戳为秒
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			payload["timestamp"] = ts
+			payload["sign"] = hmacBase64(ts+"\n"+c.secret, "")
+		}
+		body, err := postJSON(c.client, c.url, payload)
+		if err != nil {
+			return string(body), err
+		}
+		var resp struct {
+			Code       *int   `json:"code"`
+			StatusCode *int   `json:"StatusCode"`
+			Msg        string `json:"msg"`
+		}
+		if json.Unmarshal(body, &resp) == nil {
+			if resp.Code != nil && *resp.Code != 0 {
+				return string(body), fmt.Errorf("飞书返回错误 %d: %s", *resp.Code, resp.Msg)
+			}
+			if resp.StatusCode != nil && *resp.StatusCode != 0 {
+				return string(body), fmt.Errorf("飞书返回错误 %d", *resp.StatusCode)
+			}
+		}
+		return string(body), nil
+	}
+	return "", fmt.Errorf("不支持的渠道类型: %s", c.kind)
+}
+
+// checkErrcode 检查钉钉、企业微信返回的 errcode，返回响应内容
+func checkErrcode(body []byte, err error) (string, error) {
+	if err != nil {
+		return string(body), err
+	}
+	var resp struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(body, &resp) == nil && resp.Errcode != 0 {
+		return string(body), fmt.Errorf("机器人返回错误 %d: %s", resp.Errcode, resp.Errmsg)
+	}
+	return string(body), nil
+}
+
+func hmacBase64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func separator(u string) string {
+	if strings.Contains(u, "?") {
+		return "&"
+	}
+	return "?"
+}
+
+// TelegramNotifier 通过 Telegram 机器人的 sendMessage 接口发送消息
+type TelegramNotifier struct {
+	name   string
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func newTelegramNotifier(ch config.ChannelConfig) (*TelegramNotifier, error) {
+	if ch.BotToken == "" || ch.ChatID == "" {
+		return nil, fmt.Errorf("缺少 bot_token 或 chat_id")
+	}
+	apiURL := strings.TrimRight(ch.URL, "/")
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+	return &TelegramNotifier{name: ch.Name, apiURL: apiURL, token: ch.BotToken, chatID: ch.ChatID, client: httpClient(ch)}, nil
+}
+
+func (t *TelegramNotifier) Name() string { return t.name }
+
+func (t *TelegramNotifier) Type() string { return "telegram" }
+
+func (t *TelegramNotifier) Send(n Notification) (string, error) {
+	body, err := postJSON(t.client, t.apiURL+"/bot"+t.token+"/sendMessage", map[string]string{
+		"chat_id": t.chatID,
+		"text":    n.Text(),
+	})
+	if err != nil {
+		// 不在错误中暴露机器人 token
+		return string(body), fmt.Errorf("%s", strings.ReplaceAll(err.Error(), t.token, "***"))
+	}
+	var resp struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	// Bot API 总是返回 JSON，无法解析的应答（如代理的错误页）视为发送失败
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return string(body), fmt.Errorf("无法解析Telegram应答: %v", err)
+	}
+	if !resp.Ok {
+		return string(body), fmt.Errorf("Telegram返回错误: %s", resp.Description)
+	}
+	return string(body), nil
+}
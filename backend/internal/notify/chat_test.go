@@ -0,0 +1,246 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+func decodePayload(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v: %s", err, body)
+	}
+	return payload
+}
+
+func hmacSHA256Base64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestSlackPayload(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, "ok")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "slack", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	payload := decodePayload(t, (*requests)[0].body)
+	if payload["text"] != testNotification.Text() {
+		t.Errorf("text = %v, want %q", payload["text"], testNotification.Text())
+	}
+}
+
+func TestTeamsPayload(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, "1")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "teams", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	payload := decodePayload(t, (*requests)[0].body)
+	if payload["@type"] != "MessageCard" || payload["title"] != testNotification.Subject {
+		t.Errorf("unexpected card: %v", payload)
+	}
+	// Teams 的 Markdown 需要行尾两个空格才换行
+	if want := "10.0.0.1  \n连续失败 3 次"; payload["text"] != want {
+		t.Errorf("text = %q, want %q", payload["text"], want)
+	}
+}
+
+func TestDingTalkSigning(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "dingtalk", URL: srv.URL + "/robot/send?access_token=abc", Secret: "SECdemo"})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := (*requests)[0]
+	if req.query["access_token"] != "abc" {
+		t.Errorf("access_token = %q, want the configured token to be kept", req.query["access_token"])
+	}
+	ts := req.query["timestamp"]
+	if len(ts) != 13 {
+		t.Fatalf("timestamp = %q, want milliseconds", ts)
+	}
+	if want := hmacSHA256Base64("SECdemo", ts+"\nSECdemo"); req.query["sign"] != want {
+		t.Errorf("sign = %q, want %q", req.query["sign"], want)
+	}
+
+	payload := decodePayload(t, req.body)
+	text, _ := payload["text"].(map[string]interface{})
+	if payload["msgtype"] != "text" || text["content"] != testNotification.Text() {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestDingTalkWithoutSecretIsUnsigned(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, `{"errcode":0}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "dingtalk", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if q := (*requests)[0].query; q["timestamp"] != "" || q["sign"] != "" {
+		t.Errorf("unexpected signature parameters: %v", q)
+	}
+}
+
+func TestErrcodeIsError(t *testing.T) {
+	for _, kind := range []string{"dingtalk", "wecom"} {
+		t.Run(kind, func(t *testing.T) {
+			body := `{"errcode":310000,"errmsg":"sign not match"}`
+			srv, _ := newTestServer(t, http.StatusOK, body)
+			n := newTestNotifier(t, config.ChannelConfig{Type: kind, URL: srv.URL})
+
+			response, err := n.Send(testNotification)
+			if err == nil || !strings.Contains(err.Error(), "310000") || !strings.Contains(err.Error(), "sign not match") {
+				t.Errorf("err = %v, want errcode 310000", err)
+			}
+			if response != body {
+				t.Errorf("response = %q, want %q", response, body)
+			}
+		})
+	}
+}
+
+func TestWeComPayload(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "wecom", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	payload := decodePayload(t, (*requests)[0].body)
+	text, _ := payload["text"].(map[string]interface{})
+	if payload["msgtype"] != "text" || text["content"] != testNotification.Text() {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestFeishuSigning(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "feishu", URL: srv.URL, Secret: "feishu-secret"})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	payload := decodePayload(t, (*requests)[0].body)
+	ts, _ := payload["timestamp"].(string)
+	if len(ts) != 10 {
+		t.Fatalf("timestamp = %v, want Unix seconds", payload["timestamp"])
+	}
+	// 飞书以 timestamp + "\n" + secret 为密钥，对空字符串签名
+	if want := hmacSHA256Base64(ts+"\nfeishu-secret", ""); payload["sign"] != want {
+		t.Errorf("sign = %v, want %q", payload["sign"], want)
+	}
+	content, _ := payload["content"].(map[string]interface{})
+	if payload["msg_type"] != "text" || content["text"] != testNotification.Text() {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestFeishuErrorCodes(t *testing.T) {
+	tests := []struct {
+		response string
+		wantErr  string
+	}{
+		{`{"code":0,"msg":"success"}`, ""},
+		{`{"StatusCode":0,"StatusMessage":"success"}`, ""},
+		{`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`, "19021"},
+		{`{"StatusCode":9499,"StatusMessage":"Bad Request"}`, "9499"},
+	}
+	for _, tt := range tests {
+		srv, _ := newTestServer(t, http.StatusOK, tt.response)
+		n := newTestNotifier(t, config.ChannelConfig{Type: "feishu", URL: srv.URL})
+
+		response, err := n.Send(testNotification)
+		if response != tt.response {
+			t.Errorf("response = %q, want %q", response, tt.response)
+		}
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.response, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: err = %v, want %s", tt.response, err, tt.wantErr)
+		}
+	}
+}
+
+func TestChatNon2xxIsError(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusForbidden, "invalid_token")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "slack", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Errorf("err = %v, want HTTP 403", err)
+	}
+}
+
+func TestTelegramPayload(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, `{"ok":true,"result":{"message_id":1}}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "telegram", URL: srv.URL + "/", BotToken: "123:ABC", ChatID: "-100200"})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := (*requests)[0]
+	if req.path != "/bot123:ABC/sendMessage" {
+		t.Errorf("path = %q", req.path)
+	}
+	payload := decodePayload(t, req.body)
+	if payload["chat_id"] != "-100200" || payload["text"] != testNotification.Text() {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestTelegramErrors(t *testing.T) {
+	tests := []struct {
+		status   int
+		response string
+		wantErr  string
+	}{
+		{http.StatusOK, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, "chat not found"},
+		{http.StatusOK, "<html>Bad Gateway</html>", "无法解析"},
+		{http.StatusUnauthorized, `{"ok":false,"error_code":401,"description":"Unauthorized"}`, "HTTP 401"},
+	}
+	for _, tt := range tests {
+		srv, _ := newTestServer(t, tt.status, tt.response)
+		n := newTestNotifier(t, config.ChannelConfig{Type: "telegram", URL: srv.URL, BotToken: "123:S3CRET", ChatID: "1"})
+
+		response, err := n.Send(testNotification)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %s", tt.response, err, tt.wantErr)
+			continue
+		}
+		if response != tt.response {
+			t.Errorf("response = %q, want %q", response, tt.response)
+		}
+		// 机器人 token 出现在请求地址中，不能随错误写入通知记录
+		if strings.Contains(err.Error(), "S3CRET") {
+			t.Errorf("error leaks bot token: %v", err)
+		}
+	}
+}
+
+func TestTelegramRedactsTokenFromRequestErrors(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusOK, `{"ok":true}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "telegram", URL: srv.URL, BotToken: "123:S3CRET", ChatID: "1"})
+	srv.Close()
+
+	_, err := n.Send(testNotification)
+	if err == nil {
+		t.Fatal("Send to a closed server succeeded")
+	}
+	if strings.Contains(err.Error(), "S3CRET") {
+		t.Errorf("error leaks bot token: %v", err)
+	}
+}
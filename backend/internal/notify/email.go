@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+)
+
+// EmailNotifier 通过 SMTP 发送邮件
+type EmailNotifier struct {
+	name   string
+	sender *email.EmailSender
+}
+
+func NewEmailNotifier(name string, cfg *config.EmailConfig) *EmailNotifier {
+	return &EmailNotifier{name: name, sender: email.NewEmailSender(cfg)}
+}
+
+func (e *EmailNotifier) Name() string { return e.name }
+
+func (e *EmailNotifier) Type() string { return "email" }
+
+func (e *EmailNotifier) Send(n Notification) (string, error) {
+	return e.sender.SendMessage(n.To, email.Message{Subject: n.Subject, Text: n.Body, HTML: n.HTML, Attachments: n.Attachments})
+}
@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// 事件操作，见 Notification.Action
+const (
+	ActionTrigger     = "trigger"
+	ActionAcknowledge = "acknowledge"
+	ActionResolve     = "resolve"
+)
+
+// incidentSource 事件来源，显示在 PagerDuty、Opsgenie 中
+const incidentSource = "go-ssl-monitor"
+
+// IncidentNotifier 在 PagerDuty（Events API v2）或 Opsgenie（Alert API）中创建事件，
+// 以告警的去重键合并重复触发，告警确认、解决时确认、关闭对应的事件
+type IncidentNotifier struct {
+	name   string
+	kind   string
+	url    string
+	key    string
+	client *http.Client
+}
+
+func newIncidentNotifier(ch config.ChannelConfig) (*IncidentNotifier, error) {
+	n := &IncidentNotifier{name: ch.Name, kind: ch.Type, url: strings.TrimRight(ch.URL, "/"), client: httpClient(ch)}
+	switch ch.Type {
+	case "pagerduty":
+		if ch.RoutingKey == "" {
+			return nil, fmt.Errorf("缺少 routing_key")
+		}
+		n.key = ch.RoutingKey
+		if n.url == "" {
+			n.url = "https://events.pagerduty.com/v2/enqueue"
+		}
+	default:
+		if ch.APIKey == "" {
+			return nil, fmt.Errorf("缺少 api_key")
+		}
+		n.key = ch.APIKey
+		if n.url == "" {
+			n.url = "https://api.opsgenie.com/v2/alerts"
+		}
+	}
+	return n, nil
+}
+
+func (i *IncidentNotifier) Name() string { return i.name }
+
+func (i *IncidentNotifier) Type() string { return i.kind }
+
+func (i *IncidentNotifier) Send(n Notification) (string, error) {
+	action := n.Action
+	if action == "" {
+		action = ActionTrigger
+	}
+	if action != ActionTrigger && n.DedupKey == "" {
+		return "", fmt.Errorf("%s 需要去重键", action)
+	}
+	if i.kind == "pagerduty" {
+		return i.sendPagerDuty(action, n)
+	}
+	return i.sendOpsgenie(action, n)
+}
+
+// sendPagerDuty 发送 Events API v2 事件，未指定去重键时由 PagerDuty 生成
+func (i *IncidentNotifier) sendPagerDuty(action string, n Notification) (string, error) {
+	event := map[string]interface{}{
+		"routing_key":  i.key,
+		"event_action": action,
+	}
+	if n.DedupKey != "" {
+		event["dedup_key"] = n.DedupKey
+	}
+	if action == ActionTrigger {
+		severity := n.Severity
+		if severity != "info" && severity != "critical" {
+			severity = "warning"
+		}
+		event["payload"] = map[string]interface{}{
+			"summary":  truncateRunes(n.Subject, 1024),
+			"source":   incidentSource,
+			"severity": severity,
+			"class":    n.Kind,
+			"custom_details": map[string]string{
+				"body": n.Body,
+			},
+		}
+	}
+	return i.post(i.url, event, nil)
+}
+
+// sendOpsgenie 创建告警，或按 alias 确认、关闭告警
+func (i *IncidentNotifier) sendOpsgenie(action string, n Notification) (string, error) {
+	header := http.Header{"Authorization": {"GenieKey " + i.key}}
+	if action != ActionTrigger {
+		endpoint := "close"
+		if action == ActionAcknowledge {
+			endpoint = "acknowledge"
+		}
+		target := i.url + "/" + url.PathEscape(n.DedupKey) + "/" + endpoint + "?identifierType=alias"
+		return i.post(target, map[string]string{"source": incidentSource}, header)
+	}
+
+	priority := "P3"
+	switch n.Severity {
+	case "critical":
+		priority = "P1"
+	case "info":
+		priority = "P5"
+	}
+	alert := map[string]interface{}{
+		"message":     truncateRunes(n.Subject, 130),
+		"description": truncateRunes(n.Body, 15000),
+		"priority":    priority,
+		"source":      incidentSource,
+		"tags":        []string{n.Kind},
+	}
+	if n.DedupKey != "" {
+		alert["alias"] = n.DedupKey
+	}
+	return i.post(i.url, alert, header)
+}
+
+func (i *IncidentNotifier) post(target string, v interface{}, header http.Header) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := do(i.client, req)
+	return string(body), err
+}
+
+// TracksIncidents 判断渠道是否按去重键管理事件，这类渠道在告警确认、解决时会收到后续操作
+func TracksIncidents(channel string) bool {
+	n, ok := Get(channel)
+	if !ok {
+		return false
+	}
+	_, ok = n.(*IncidentNotifier)
+	return ok
+}
+
+// truncateRunes 按字符截断，避免截断多字节字符
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+var incidentNotification = Notification{
+	Kind:     "backup_alert",
+	Subject:  "备份失败: 10.0.0.1",
+	Body:     "连续失败 3 次",
+	Severity: "critical",
+	DedupKey: "backup_failure:10.0.0.1#42",
+}
+
+func TestPagerDutyTrigger(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusAccepted, `{"status":"success","dedup_key":"backup_failure:10.0.0.1#42"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "pagerduty", URL: srv.URL + "/v2/enqueue", RoutingKey: "R0UT1NG"})
+
+	response, err := n.Send(incidentNotification)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !strings.Contains(response, "success") {
+		t.Errorf("response = %q", response)
+	}
+	req := (*requests)[0]
+	if req.path != "/v2/enqueue" {
+		t.Errorf("path = %q", req.path)
+	}
+	event := decodePayload(t, req.body)
+	for key, want := range map[string]interface{}{
+		"routing_key":  "R0UT1NG",
+		"event_action": ActionTrigger,
+		"dedup_key":    incidentNotification.DedupKey,
+	} {
+		if event[key] != want {
+			t.Errorf("event[%s] = %v, want %v", key, event[key], want)
+		}
+	}
+	payload, _ := event["payload"].(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"summary":  incidentNotification.Subject,
+		"source":   incidentSource,
+		"severity": "critical",
+		"class":    incidentNotification.Kind,
+	} {
+		if payload[key] != want {
+			t.Errorf("payload[%s] = %v, want %v", key, payload[key], want)
+		}
+	}
+}
+
+func TestPagerDutySeverityFallsBackToWarning(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusAccepted, `{"status":"success"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "pagerduty", URL: srv.URL, RoutingKey: "R0UT1NG"})
+
+	if _, err := n.Send(Notification{Subject: "test"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	event := decodePayload(t, (*requests)[0].body)
+	if _, ok := event["dedup_key"]; ok {
+		t.Errorf("dedup_key should be left to PagerDuty when empty: %v", event)
+	}
+	payload, _ := event["payload"].(map[string]interface{})
+	if payload["severity"] != "warning" {
+		t.Errorf("severity = %v, want warning", payload["severity"])
+	}
+}
+
+func TestPagerDutyFollowUp(t *testing.T) {
+	for _, action := range []string{ActionAcknowledge, ActionResolve} {
+		t.Run(action, func(t *testing.T) {
+			srv, requests := newTestServer(t, http.StatusAccepted, `{"status":"success"}`)
+			n := newTestNotifier(t, config.ChannelConfig{Type: "pagerduty", URL: srv.URL, RoutingKey: "R0UT1NG"})
+
+			follow := incidentNotification
+			follow.Action = action
+			if _, err := n.Send(follow); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			event := decodePayload(t, (*requests)[0].body)
+			if event["event_action"] != action || event["dedup_key"] != incidentNotification.DedupKey {
+				t.Errorf("unexpected event: %v", event)
+			}
+			if _, ok := event["payload"]; ok {
+				t.Errorf("%s event should not carry a payload: %v", action, event)
+			}
+		})
+	}
+}
+
+func TestOpsgenieTrigger(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusAccepted, `{"result":"Request will be processed","requestId":"abc"}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "opsgenie", URL: srv.URL + "/v2/alerts/", APIKey: "g3n13"})
+
+	if _, err := n.Send(incidentNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := (*requests)[0]
+	if req.path != "/v2/alerts" {
+		t.Errorf("path = %q", req.path)
+	}
+	if auth := req.header.Get("Authorization"); auth != "GenieKey g3n13" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	alert := decodePayload(t, req.body)
+	for key, want := range map[string]interface{}{
+		"message":     incidentNotification.Subject,
+		"description": incidentNotification.Body,
+		"alias":       incidentNotification.DedupKey,
+		"priority":    "P1",
+		"source":      incidentSource,
+	} {
+		if alert[key] != want {
+			t.Errorf("alert[%s] = %v, want %v", key, alert[key], want)
+		}
+	}
+}
+
+func TestOpsgenieFollowUp(t *testing.T) {
+	tests := map[string]string{
+		ActionAcknowledge: "acknowledge",
+		ActionResolve:     "close",
+	}
+	for action, endpoint := range tests {
+		t.Run(action, func(t *testing.T) {
+			srv, requests := newTestServer(t, http.StatusAccepted, `{"result":"Request will be processed"}`)
+			n := newTestNotifier(t, config.ChannelConfig{Type: "opsgenie", URL: srv.URL, APIKey: "g3n13"})
+
+			follow := incidentNotification
+			follow.Action = action
+			if _, err := n.Send(follow); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			req := (*requests)[0]
+			// 去重键作为 alias 放在路径中，需要转义
+			if want := "/backup_failure:10.0.0.1#42/" + endpoint; req.path != want {
+				t.Errorf("path = %q, want %q", req.path, want)
+			}
+			if req.query["identifierType"] != "alias" {
+				t.Errorf("identifierType = %q, want alias", req.query["identifierType"])
+			}
+			if req.header.Get("Authorization") != "GenieKey g3n13" {
+				t.Errorf("Authorization = %q", req.header.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestIncidentFollowUpRequiresDedupKey(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusAccepted, `{}`)
+	for _, kind := range []string{"pagerduty", "opsgenie"} {
+		n := newTestNotifier(t, config.ChannelConfig{Type: kind, URL: srv.URL, RoutingKey: "R0UT1NG", APIKey: "g3n13"})
+		if _, err := n.Send(Notification{Subject: "test", Action: ActionResolve}); err == nil {
+			t.Errorf("%s: resolve without dedup key succeeded", kind)
+		}
+	}
+	if len(*requests) != 0 {
+		t.Errorf("sent %d requests without a dedup key", len(*requests))
+	}
+}
+
+func TestIncidentHTTPErrorIsError(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusBadRequest, `{"status":"invalid event","errors":["'routing_key' is invalid"]}`)
+	n := newTestNotifier(t, config.ChannelConfig{Type: "pagerduty", URL: srv.URL, RoutingKey: "bad"})
+
+	response, err := n.Send(incidentNotification)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400") {
+		t.Fatalf("err = %v, want HTTP 400", err)
+	}
+	if !strings.Contains(response, "invalid event") {
+		t.Errorf("response = %q", response)
+	}
+}
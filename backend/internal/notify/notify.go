@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+)
+
+// Notification 一条待发送的通知
+type Notification struct {
+	Kind    string   `json:"kind"`
+	To      []string `json:"recipients,omitempty"` // 邮件收件人，为空时使用默认收件人；其他渠道忽略
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	HTML    string   `json:"html,omitempty"` // 邮件的 HTML 部分，其他渠道使用 Body
+
+	Severity string `json:"severity,omitempty"` // info、warning、critical
+	DedupKey string `json:"dedupKey,omitempty"` // 告警的去重键，PagerDuty、Opsgenie 据此合并和关闭事件
+	Action   string `json:"action,omitempty"`   // trigger（默认）、acknowledge、resolve，见 TracksIncidents
+
+	Attachments []email.Attachment `json:"-"` // 邮件附件，其他渠道忽略
+}
+
+// Text 返回聊天类渠道使用的纯文本内容
+func (n Notification) Text() string {
+	return n.Subject + "\n" + n.Body
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	Name() string
+	Type() string
+	// Send 发送通知，返回渠道的应答（SMTP 服务器的应答、HTTP 响应内容），用于发送记录
+	Send(n Notification) (string, error)
+}
+
+var (
+	mu                sync.RWMutex
+	registry          = map[string]Notifier{}
+	routes            []config.NotificationRoute
+	defaultChannels   = []string{"email"}
+	rules             []config.RoutingRule
+	groups            = map[string]recipientGroup{}
+	defaultRecipients []string
+)
+
+// Init 根据配置创建通知渠道并设置路由，email 渠道未配置时使用邮件配置自动创建
+func Init(cfg config.NotificationConfig, emailCfg *config.EmailConfig) error {
+	notifiers := map[string]Notifier{"email": NewEmailNotifier("email", emailCfg)}
+	for _, ch := range cfg.Channels {
+		if ch.Name == "" {
+			return fmt.Errorf("通知渠道缺少名称")
+		}
+		n, err := New(ch, emailCfg)
+		if err != nil {
+			return fmt.Errorf("通知渠道 %s: %w", ch.Name, err)
+		}
+		notifiers[ch.Name] = n
+	}
+
+	check := func(names []string) error {
+		for _, name := range names {
+			if _, ok := notifiers[name]; !ok {
+				return fmt.Errorf("未定义的通知渠道: %s", name)
+			}
+		}
+		return nil
+	}
+	for _, r := range cfg.Routes {
+		if err := check(r.Channels); err != nil {
+			return err
+		}
+	}
+	defaults := cfg.DefaultChannels
+	if len(defaults) == 0 {
+		defaults = []string{"email"}
+	}
+	if err := check(defaults); err != nil {
+		return err
+	}
+
+	recipientGroups := map[string]recipientGroup{}
+	for _, g := range cfg.RecipientGroups {
+		if g.Name == "" {
+			return fmt.Errorf("人员组缺少名称")
+		}
+		if err := check(g.Channels); err != nil {
+			return fmt.Errorf("人员组 %s: %w", g.Name, err)
+		}
+		quiet, err := parseQuietHours(g.QuietHours)
+		if err != nil {
+			return fmt.Errorf("人员组 %s: %w", g.Name, err)
+		}
+		recipientGroups[g.Name] = recipientGroup{RecipientGroup: g, quiet: quiet}
+	}
+	for _, r := range cfg.Rules {
+		if err := check(r.Channels); err != nil {
+			return fmt.Errorf("路由规则 %s: %w", r.Name, err)
+		}
+		for _, name := range r.Groups {
+			if _, ok := recipientGroups[name]; !ok {
+				return fmt.Errorf("路由规则 %s: 未定义的人员组: %s", r.Name, name)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	registry = notifiers
+	routes = cfg.Routes
+	defaultChannels = defaults
+	rules = cfg.Rules
+	groups = recipientGroups
+	defaultRecipients = emailCfg.ToAddresses
+	return nil
+}
+
+// New 根据渠道配置创建通知渠道
+func New(ch config.ChannelConfig, emailCfg *config.EmailConfig) (Notifier, error) {
+	switch ch.Type {
+	case "email":
+		return NewEmailNotifier(ch.Name, emailCfg), nil
+	case "webhook":
+		return newWebhookNotifier(ch)
+	case "slack", "teams", "dingtalk", "wecom", "feishu":
+		if ch.URL == "" {
+			return nil, fmt.Errorf("缺少 url")
+		}
+		return &chatNotifier{name: ch.Name, kind: ch.Type, url: ch.URL, secret: ch.Secret, client: httpClient(ch)}, nil
+	case "telegram":
+		return newTelegramNotifier(ch)
+	case "pagerduty", "opsgenie":
+		return newIncidentNotifier(ch)
+	default:
+		return nil, fmt.Errorf("不支持的渠道类型: %s", ch.Type)
+	}
+}
+
+// Register 注册或替换通知渠道
+func Register(n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[n.Name()] = n
+}
+
+// Get 按名称获取通知渠道
+func Get(name string) (Notifier, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	n, ok := registry[name]
+	return n, ok
+}
+
+// Channels 返回所有已注册的渠道，按名称排序
+func Channels() []Notifier {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]Notifier, 0, len(registry))
+	for _, n := range registry {
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// ChannelsFor 返回某类通知应发送到的渠道：合并所有匹配路由的渠道，没有匹配时使用默认渠道
+func ChannelsFor(kind string) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	seen := map[string]bool{}
+	var names []string
+	for _, r := range routes {
+		if len(r.Kinds) > 0 && !contains(r.Kinds, kind) {
+			continue
+		}
+		for _, name := range r.Channels {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, defaultChannels...)
+	}
+	return names
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// Labels 告警对象（服务器或域名）的路由属性
+type Labels struct {
+	Tags        []string `json:"tags"`
+	Environment string   `json:"environment"`
+	OwnerTeam   string   `json:"ownerTeam"`
+}
+
+// Event 待路由的通知
+type Event struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"` // info、warning、critical
+	Labels
+	To []string `json:"to"` // 事件自带的邮件收件人，如域名的通知邮箱、升级收件人
+}
+
+// Delivery 路由结果中的一个渠道及其收件人
+type Delivery struct {
+	Channel   string     `json:"channel"`
+	To        []string   `json:"to,omitempty"`        // 邮件收件人，其他渠道为空
+	Groups    []string   `json:"groups,omitempty"`    // 经由哪些人员组
+	Rotations []string   `json:"rotations,omitempty"` // 经由哪些值班轮换
+	NotBefore *time.Time `json:"notBefore,omitempty"` // 人员组处于安静时段时推迟到的时间，为空表示立即发送
+}
+
+// RoutePlan 一条通知的路由结果
+type RoutePlan struct {
+	Rules      []string   `json:"rules"` // 匹配的规则，为空表示按 routes / default_channels 发送
+	Deliveries []Delivery `json:"deliveries"`
+	Warnings   []string   `json:"warnings,omitempty"` // 无法确定值班人等问题，相应通知改发给默认收件人
+}
+
+// onCallLookup 按值班轮换名称查询当前值班人的邮箱，由 SetOnCallLookup 设置
+var onCallLookup func(rotation string, at time.Time) (string, error)
+
+// SetOnCallLookup 设置查询值班人的方法，路由规则的 rotations 依赖它
+func SetOnCallLookup(lookup func(rotation string, at time.Time) (string, error)) {
+	mu.Lock()
+	defer mu.Unlock()
+	onCallLookup = lookup
+}
+
+// recipientGroup 解析后的人员组
+type recipientGroup struct {
+	config.RecipientGroup
+	quiet *quietHours
+}
+
+// quietHours 解析后的安静时段，start、end 为一天中的分钟数
+type quietHours struct {
+	start, end  int
+	loc         *time.Location
+	minSeverity string
+}
+
+// severityRank 通知级别的高低，未知级别视为 warning
+func severityRank(severity string) int {
+	switch severity {
+	case "info":
+		return 0
+	case "critical":
+		return 2
+	default:
+		return 1
+	}
+}
+
+func parseQuietHours(q *config.QuietHours) (*quietHours, error) {
+	if q == nil {
+		return nil, nil
+	}
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return nil, fmt.Errorf("无效的安静时段开始时间: %s", q.Start)
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return nil, fmt.Errorf("无效的安静时段结束时间: %s", q.End)
+	}
+	loc := time.Local
+	if q.Timezone != "" {
+		if loc, err = time.LoadLocation(q.Timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", q.Timezone)
+		}
+	}
+	minSeverity := q.MinSeverity
+	if minSeverity == "" {
+		minSeverity = "critical"
+	}
+	return &quietHours{
+		start:       start.Hour()*60 + start.Minute(),
+		end:         end.Hour()*60 + end.Minute(),
+		loc:         loc,
+		minSeverity: minSeverity,
+	}, nil
+}
+
+// until 返回 now 所在安静时段的结束时间，不在安静时段时返回零值
+func (q *quietHours) until(now time.Time) time.Time {
+	t := now.In(q.loc)
+	minute := t.Hour()*60 + t.Minute()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.loc)
+	at := func(day time.Time, minutes int) time.Time { return day.Add(time.Duration(minutes) * time.Minute) }
+
+	switch {
+	case q.start < q.end:
+		if minute >= q.start && minute < q.end {
+			return at(midnight, q.end)
+		}
+	case q.start > q.end: // 跨午夜
+		if minute >= q.start {
+			return at(midnight.AddDate(0, 0, 1), q.end)
+		}
+		if minute < q.end {
+			return at(midnight, q.end)
+		}
+	}
+	return time.Time{}
+}
+
+// ruleMatches 判断规则是否匹配事件
+func ruleMatches(r config.RoutingRule, e Event) bool {
+	if len(r.Kinds) > 0 && !contains(r.Kinds, e.Kind) {
+		return false
+	}
+	if len(r.Severities) > 0 && !contains(r.Severities, e.Severity) {
+		return false
+	}
+	if len(r.Environments) > 0 && !contains(r.Environments, e.Environment) {
+		return false
+	}
+	if len(r.OwnerTeams) > 0 && !contains(r.OwnerTeams, e.OwnerTeam) {
+		return false
+	}
+	if len(r.Tags) > 0 {
+		for _, tag := range e.Tags {
+			if contains(r.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Route 计算通知应发送到的渠道和收件人：合并所有匹配规则的渠道及人员组，
+// 没有匹配规则时按通知类型的 routes 或 default_channels 发送给事件自带的收件人或默认收件人。
+// 人员组处于安静时段且通知级别低于 min_severity 时，该组的通知推迟到安静时段结束；
+// 值班轮换的通知发送给 now 时刻的值班人，不受安静时段限制，无法确定值班人时发送给默认收件人
+func Route(e Event, now time.Time) RoutePlan {
+	mu.RLock()
+	matched := make([]config.RoutingRule, 0, len(rules))
+	for _, r := range rules {
+		if ruleMatches(r, e) {
+			matched = append(matched, r)
+		}
+	}
+	lookup := onCallLookup
+	mu.RUnlock()
+
+	plan := &planBuilder{plan: RoutePlan{Rules: []string{}, Deliveries: []Delivery{}}}
+	if len(matched) == 0 {
+		for _, channel := range ChannelsFor(e.Kind) {
+			plan.add(channel, e.To, "", time.Time{})
+		}
+		return plan.plan
+	}
+
+	for _, r := range matched {
+		plan.plan.Rules = append(plan.plan.Rules, r.Name)
+		for _, channel := range r.Channels {
+			plan.add(channel, e.To, "", time.Time{})
+		}
+		for _, name := range r.Groups {
+			g, ok := group(name)
+			if !ok {
+				continue
+			}
+			var notBefore time.Time
+			if g.quiet != nil && severityRank(e.Severity) < severityRank(g.quiet.minSeverity) {
+				notBefore = g.quiet.until(now)
+			}
+			if len(g.Emails) > 0 {
+				plan.add("email", g.Emails, g.Name, notBefore)
+			}
+			for _, channel := range g.Channels {
+				plan.add(channel, g.Emails, g.Name, notBefore)
+			}
+		}
+		for _, name := range r.Rotations {
+			var to []string
+			if lookup == nil {
+				plan.warn(fmt.Sprintf("值班轮换 %s: 未启用值班查询", name))
+			} else if addr, err := lookup(name, now); err != nil {
+				plan.warn(fmt.Sprintf("值班轮换 %s: %v", name, err))
+			} else {
+				to = []string{addr}
+			}
+			d := plan.add("email", to, "", time.Time{})
+			if !contains(d.Rotations, name) {
+				d.Rotations = append(d.Rotations, name)
+			}
+		}
+	}
+	if len(e.To) > 0 {
+		plan.add("email", e.To, "", time.Time{})
+	}
+	return plan.plan
+}
+
+// sendAt 返回推迟发送的时间，立即发送时为零值
+func (d Delivery) sendAt() time.Time {
+	if d.NotBefore == nil {
+		return time.Time{}
+	}
+	return *d.NotBefore
+}
+
+// planBuilder 合并同一渠道、同一发送时间的收件人
+type planBuilder struct {
+	plan RoutePlan
+}
+
+// add 将收件人合并到渠道的发送计划中，返回合并到的发送计划
+func (b *planBuilder) add(channel string, to []string, groupName string, notBefore time.Time) *Delivery {
+	isEmail := false
+	if n, ok := Get(channel); ok && n.Type() == "email" {
+		isEmail = true
+		if len(to) == 0 {
+			to = DefaultRecipients()
+		}
+	}
+
+	var d *Delivery
+	for i := range b.plan.Deliveries {
+		if b.plan.Deliveries[i].Channel == channel && b.plan.Deliveries[i].sendAt().Equal(notBefore) {
+			d = &b.plan.Deliveries[i]
+			break
+		}
+	}
+	if d == nil {
+		delivery := Delivery{Channel: channel}
+		if !notBefore.IsZero() {
+			delivery.NotBefore = &notBefore
+		}
+		b.plan.Deliveries = append(b.plan.Deliveries, delivery)
+		d = &b.plan.Deliveries[len(b.plan.Deliveries)-1]
+	}
+	if isEmail {
+		for _, addr := range to {
+			if !contains(d.To, addr) {
+				d.To = append(d.To, addr)
+			}
+		}
+	}
+	if groupName != "" && !contains(d.Groups, groupName) {
+		d.Groups = append(d.Groups, groupName)
+	}
+	return d
+}
+
+func (b *planBuilder) warn(msg string) {
+	log.Printf("Notify: %s, falling back to default recipients", msg)
+	b.plan.Warnings = append(b.plan.Warnings, msg)
+}
+
+// DefaultRecipients 返回邮件的默认收件人
+func DefaultRecipients() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string(nil), defaultRecipients...)
+}
+
+func group(name string) (recipientGroup, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	g, ok := groups[name]
+	return g, ok
+}
+
+// Rules 返回配置的路由规则
+func Rules() []config.RoutingRule {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]config.RoutingRule{}, rules...)
+}
+
+// RecipientGroups 返回配置的人员组
+func RecipientGroups() []config.RecipientGroup {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]config.RecipientGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g.RecipientGroup)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// WebhookNotifier 以 JSON 形式 POST 通知，配置了密钥时附带 HMAC-SHA256 签名：
+// X-Signature-256: sha256=hex(HMAC(secret, timestamp + "." + body))，X-Timestamp 为 Unix 秒
+type WebhookNotifier struct {
+	name   string
+	url    string
+	secret string
+	client *http.Client
+}
+
+func newWebhookNotifier(ch config.ChannelConfig) (*WebhookNotifier, error) {
+	if ch.URL == "" {
+		return nil, fmt.Errorf("缺少 url")
+	}
+	return &WebhookNotifier{name: ch.Name, url: ch.URL, secret: ch.Secret, client: httpClient(ch)}, nil
+}
+
+func (w *WebhookNotifier) Name() string { return w.name }
+
+func (w *WebhookNotifier) Type() string { return "webhook" }
+
+func (w *WebhookNotifier) Send(n Notification) (string, error) {
+	timestamp := time.Now().Unix()
+	payload, err := json.Marshal(struct {
+		Notification
+		Timestamp int64 `json:"timestamp"`
+	}{n, timestamp})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		ts := strconv.FormatInt(timestamp, 10)
+		req.Header.Set("X-Timestamp", ts)
+		req.Header.Set("X-Signature-256", "sha256="+Sign(w.secret, ts, payload))
+	}
+
+	body, err := do(w.client, req)
+	return string(body), err
+}
+
+// Sign 计算 webhook 签名，接收方可用同样的方法校验
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func httpClient(ch config.ChannelConfig) *http.Client {
+	timeout := 10 * time.Second
+	if ch.Timeout > 0 {
+		timeout = time.Duration(ch.Timeout) * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// postJSON 发送 JSON 请求，返回响应内容
+func postJSON(client *http.Client, url string, v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(client, req)
+}
+
+// do 执行请求，非 2xx 响应视为失败
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		// 地址中可能包含机器人密钥，不放入错误信息
+		if ue, ok := err.(*url.Error); ok {
+			return nil, ue.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(body), 200))
+	}
+	return body, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+)
+
+// request 测试服务器收到的请求
+type request struct {
+	header http.Header
+	query  map[string]string
+	body   []byte
+	path   string
+}
+
+// newTestServer 启动记录请求的 HTTP 服务器，以 status 和 response 应答
+func newTestServer(t *testing.T, status int, response string) (*httptest.Server, *[]request) {
+	t.Helper()
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query := map[string]string{}
+		for k := range r.URL.Query() {
+			query[k] = r.URL.Query().Get(k)
+		}
+		requests = append(requests, request{header: r.Header.Clone(), query: query, body: body, path: r.URL.Path})
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func newTestNotifier(t *testing.T, ch config.ChannelConfig) Notifier {
+	t.Helper()
+	if ch.Name == "" {
+		ch.Name = ch.Type
+	}
+	n, err := New(ch, nil)
+	if err != nil {
+		t.Fatalf("New(%s): %v", ch.Type, err)
+	}
+	return n
+}
+
+var testNotification = Notification{Kind: "backup_alert", Subject: "备份失败", Body: "10.0.0.1\n连续失败 3 次", Severity: "warning"}
+
+func TestWebhookSignsPayload(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK, "ok")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "webhook", URL: srv.URL, Secret: "s3cret"})
+
+	response, err := n.Send(testNotification)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("response = %q, want %q", response, "ok")
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+
+	if ct := req.header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	ts := req.header.Get("X-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Since(time.Unix(sec, 0)) > time.Minute {
+		t.Fatalf("X-Timestamp = %q, want current Unix seconds", ts)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(ts + "."))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get("X-Signature-256"); got != want {
+		t.Errorf("X-Signature-256 = %q, want %q", got, want)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	for key, want := range map[string]interface{}{
+		"kind":      testNotification.Kind,
+		"subject":   testNotification.Subject,
+		"body":      testNotification.Body,
+		"severity":  testNotification.Severity,
+		"timestamp": float64(sec),
+	} {
+		if payload[key] != want {
+			t.Errorf("payload[%s] = %v, want %v", key, payload[key], want)
+		}
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusNoContent, "")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "webhook", URL: srv.URL})
+
+	if _, err := n.Send(testNotification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := (*requests)[0]
+	if req.header.Get("X-Signature-256") != "" || req.header.Get("X-Timestamp") != "" {
+		t.Errorf("unexpected signature headers: %v", req.header)
+	}
+}
+
+func TestWebhookNon2xxIsError(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusInternalServerError, "boom")
+	n := newTestNotifier(t, config.ChannelConfig{Type: "webhook", URL: srv.URL})
+
+	response, err := n.Send(testNotification)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Fatalf("err = %v, want HTTP 500", err)
+	}
+	if response != "boom" {
+		t.Errorf("response = %q, want %q", response, "boom")
+	}
+}
+
+func TestSignMatchesReceiverVerification(t *testing.T) {
+	payload := []byte(`{"kind":"test"}`)
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	if got, want := Sign("key", "1700000000", payload), hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package oncall
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/model"
+	"gorm.io/gorm"
+)
+
+// Shift 一段值班
+type Shift struct {
+	Email      string    `json:"email"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	OverrideID uint      `json:"overrideId,omitempty"` // 替班时为替班记录
+}
+
+// Members 返回轮换的值班成员，按值班顺序
+func Members(r *model.OnCallRotation) []string {
+	var members []string
+	for _, m := range strings.Split(r.Members, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// Validate 校验轮换配置
+func Validate(r *model.OnCallRotation) error {
+	if r.Name == "" {
+		return errors.New("缺少名称")
+	}
+	members := Members(r)
+	if len(members) == 0 {
+		return errors.New("至少需要一位值班成员")
+	}
+	for _, m := range members {
+		if _, err := mail.ParseAddress(m); err != nil {
+			return fmt.Errorf("无效的成员邮箱: %s", m)
+		}
+	}
+	if r.Handover != model.HandoverDaily && r.Handover != model.HandoverWeekly {
+		return fmt.Errorf("交接周期必须为 %s 或 %s", model.HandoverDaily, model.HandoverWeekly)
+	}
+	if r.StartsAt.IsZero() {
+		return errors.New("缺少开始时间")
+	}
+	if _, err := location(r); err != nil {
+		return fmt.Errorf("无效的时区: %s", r.Timezone)
+	}
+	return nil
+}
+
+func location(r *model.OnCallRotation) (*time.Location, error) {
+	if r.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(r.Timezone)
+}
+
+// regularShift 返回 at 所在的常规班次，轮换开始前返回 Email 为空、结束于开始时间的班次。
+// 交接按日历日计算，夏令时切换前后交接时刻不变
+func regularShift(r *model.OnCallRotation, members []string, loc *time.Location, at time.Time) Shift {
+	start := r.StartsAt.In(loc)
+	if at.Before(start) {
+		return Shift{End: start}
+	}
+	days := 1
+	if r.Handover == model.HandoverWeekly {
+		days = 7
+	}
+	n := int(at.Sub(start) / (time.Duration(days) * 24 * time.Hour))
+	for !start.AddDate(0, 0, (n+1)*days).After(at) {
+		n++
+	}
+	for n > 0 && start.AddDate(0, 0, n*days).After(at) {
+		n--
+	}
+	return Shift{
+		Email: members[n%len(members)],
+		Start: start.AddDate(0, 0, n*days),
+		End:   start.AddDate(0, 0, (n+1)*days),
+	}
+}
+
+// shiftAt 返回 at 时刻的值班，替班优先，多个替班重叠时以最后添加的为准
+func shiftAt(r *model.OnCallRotation, members []string, loc *time.Location, overrides []model.OnCallOverride, at time.Time) Shift {
+	var override *model.OnCallOverride
+	for i := range overrides {
+		o := &overrides[i]
+		if !o.StartsAt.After(at) && o.EndsAt.After(at) && (override == nil || o.ID > override.ID) {
+			override = o
+		}
+	}
+	if override != nil {
+		return Shift{Email: override.Email, Start: override.StartsAt, End: override.EndsAt, OverrideID: override.ID}
+	}
+	return regularShift(r, members, loc, at)
+}
+
+// OnCall 返回轮换在 at 时刻的值班，轮换尚未开始且没有替班时 Email 为空
+func OnCall(db *gorm.DB, r *model.OnCallRotation, at time.Time) (Shift, error) {
+	loc, err := location(r)
+	if err != nil {
+		return Shift{}, err
+	}
+	var overrides []model.OnCallOverride
+	if err := db.Where("rotation_id = ? AND starts_at <= ? AND ends_at > ?", r.ID, at, at).Find(&overrides).Error; err != nil {
+		return Shift{}, err
+	}
+	return shiftAt(r, Members(r), loc, overrides, at), nil
+}
+
+// Schedule 返回 from 到 to 之间的值班安排，替班会拆分所在的常规班次
+func Schedule(db *gorm.DB, r *model.OnCallRotation, from, to time.Time) ([]Shift, error) {
+	loc, err := location(r)
+	if err != nil {
+		return nil, err
+	}
+	var overrides []model.OnCallOverride
+	if err := db.Where("rotation_id = ? AND starts_at < ? AND ends_at > ?", r.ID, to, from).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return schedule(r, Members(r), loc, overrides, from, to), nil
+}
+
+// schedule 在常规交接时刻和替班起止时刻切分，逐段确定值班人，合并相邻的同一班次
+func schedule(r *model.OnCallRotation, members []string, loc *time.Location, overrides []model.OnCallOverride, from, to time.Time) []Shift {
+	bounds := []time.Time{from, to}
+	for t := from; t.Before(to); {
+		s := regularShift(r, members, loc, t)
+		if !s.End.After(t) {
+			break
+		}
+		t = s.End
+		bounds = append(bounds, t)
+	}
+	for _, o := range overrides {
+		bounds = append(bounds, o.StartsAt, o.EndsAt)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	shifts := []Shift{}
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		if start.Before(from) || end.After(to) || !end.After(start) {
+			continue
+		}
+		s := shiftAt(r, members, loc, overrides, start)
+		if s.Email == "" {
+			continue
+		}
+		if n := len(shifts); n > 0 && shifts[n-1].End.Equal(start) &&
+			shifts[n-1].Email == s.Email && shifts[n-1].OverrideID == s.OverrideID {
+			shifts[n-1].End = end
+			continue
+		}
+		shifts = append(shifts, Shift{Email: s.Email, Start: start, End: end, OverrideID: s.OverrideID})
+	}
+	return shifts
+}
+
+// Lookup 返回按轮换名称查询当前值班人邮箱的函数，供通知路由使用
+func Lookup(db *gorm.DB) func(name string, at time.Time) (string, error) {
+	return func(name string, at time.Time) (string, error) {
+		var r model.OnCallRotation
+		if err := db.Where("name = ?", name).First(&r).Error; err != nil {
+			return "", errors.New("不存在")
+		}
+		s, err := OnCall(db, &r, at)
+		if err != nil {
+			return "", err
+		}
+		if s.Email == "" {
+			return "", errors.New("尚未开始，且没有替班")
+		}
+		return s.Email, nil
+	}
+}
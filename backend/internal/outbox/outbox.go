@@ -0,0 +1,392 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-ssl-monitor/internal/config"
+	"github.com/go-ssl-monitor/internal/email"
+	"github.com/go-ssl-monitor/internal/model"
+	"github.com/go-ssl-monitor/internal/notify"
+	"gorm.io/gorm"
+)
+
+// 重试退避的初始间隔和上限
+const (
+	baseBackoff = time.Minute
+	maxBackoff  = 6 * time.Hour
+)
+
+// sendLease 领取消息后的发送期限，需大于各渠道的发送超时，期间其他进程不会再次领取该消息
+const sendLease = 15 * time.Minute
+
+// ErrInFlight 消息正在发送中，不能手动重发
+var ErrInFlight = errors.New("消息正在发送中")
+
+// Message 待发送的通知
+type Message struct {
+	Kind            string
+	Severity        string        // 通知级别：info、warning、critical，用于路由规则和安静时段
+	Labels          notify.Labels // 告警对象的标签、环境和负责团队，用于路由规则
+	To              []string      // 事件自带的邮件收件人，为空时使用默认收件人
+	Subject         string
+	Body            string
+	HTML            string             // 邮件的 HTML 部分，可为空
+	Attachments     []email.Attachment // 邮件附件，其他渠道忽略
+	DedupKey        string             // 告警的去重键，告警确认、解决时据此确认、关闭 PagerDuty、Opsgenie 中的事件
+	AlertID         uint               // 关联的告警，写入发送记录，可为 0
+	DomainID        uint               // 关联的域名，可为 0
+	ServerID        uint               // 关联的服务器，可为 0
+	Ip              string             // 关联的服务器地址，可为空
+	BackupLogID     uint               // 关联的备份日志，可为 0
+	SentAlertStatus int                // 发送成功后写入备份日志的告警状态
+}
+
+// Deliver 按路由规则为每个渠道写入一条发件箱消息并立即尝试发送，发送失败时由后台任务按指数退避重试；
+// 处于安静时段的消息推迟到时段结束后由后台任务发送。返回的错误为本次发送失败或消息保存失败的渠道的错误，
+// 保存失败的消息不发送，也不在返回的消息中
+func Deliver(db *gorm.DB, m Message) ([]*model.OutboxMessage, error) {
+	var (
+		messages []*model.OutboxMessage
+		errs     []error
+	)
+	var attachments string
+	if len(m.Attachments) > 0 {
+		data, err := json.Marshal(m.Attachments)
+		if err != nil {
+			return nil, fmt.Errorf("附件编码失败: %w", err)
+		}
+		attachments = string(data)
+	}
+	now := time.Now()
+	plan := notify.Route(notify.Event{Kind: m.Kind, Severity: m.Severity, Labels: m.Labels, To: m.To}, now)
+	for _, d := range plan.Deliveries {
+		msg := &model.OutboxMessage{
+			Kind:            m.Kind,
+			Channel:         d.Channel,
+			Recipients:      strings.Join(d.To, ","),
+			Subject:         m.Subject,
+			Body:            m.Body,
+			HTMLBody:        m.HTML,
+			Attachments:     attachments,
+			Severity:        m.Severity,
+			DedupKey:        m.DedupKey,
+			Status:          model.OutboxPending,
+			AlertID:         m.AlertID,
+			DomainID:        m.DomainID,
+			ServerID:        m.ServerID,
+			Ip:              m.Ip,
+			BackupLogID:     m.BackupLogID,
+			SentAlertStatus: m.SentAlertStatus,
+		}
+		// 立即发送的消息以发送中状态写入，后台任务不会在发送期间重复领取
+		deferred := d.NotBefore != nil && d.NotBefore.After(now)
+		if deferred {
+			msg.NextAttemptAt = *d.NotBefore
+		} else {
+			msg.Status = model.OutboxSending
+			msg.NextAttemptAt = now.Add(sendLease)
+		}
+		if err := db.Create(msg).Error; err != nil {
+			// 未保存的消息无法重试和审计，不发送，由调用方处理错误
+			errs = append(errs, fmt.Errorf("%s: 保存消息失败: %w", d.Channel, err))
+			continue
+		}
+		messages = append(messages, msg)
+		if deferred {
+			continue
+		}
+		if err := attempt(db, msg, ""); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.Channel, err))
+		}
+	}
+	return messages, errors.Join(errs...)
+}
+
+// FollowUpIncident 告警确认或解决时，向已为 dedupKey 创建事件的渠道（PagerDuty、Opsgenie）发送 acknowledge 或 resolve；
+// 告警解决时尚未发出的触发消息不再发送。返回的错误为本次发送失败或消息保存失败的渠道的错误，发送失败的消息由后台任务重试
+func FollowUpIncident(db *gorm.DB, dedupKey, action string) error {
+	if dedupKey == "" {
+		return nil
+	}
+	var triggers []model.OutboxMessage
+	if err := db.Where("dedup_key = ? AND action = ?", dedupKey, "").Order("id ASC").Find(&triggers).Error; err != nil {
+		return err
+	}
+
+	sent := map[string]*model.OutboxMessage{}
+	var channels []string
+	for i := range triggers {
+		t := &triggers[i]
+		if !notify.TracksIncidents(t.Channel) {
+			continue
+		}
+		switch t.Status {
+		case model.OutboxSent:
+			if sent[t.Channel] == nil {
+				channels = append(channels, t.Channel)
+			}
+			sent[t.Channel] = t
+		case model.OutboxPending:
+			if action == notify.ActionResolve {
+				t.Status = model.OutboxSkipped
+				t.LastError = "告警已解决"
+				db.Save(t)
+			}
+		}
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		t := sent[channel]
+		msg := &model.OutboxMessage{
+			Kind:          t.Kind,
+			Channel:       channel,
+			Subject:       t.Subject,
+			Severity:      t.Severity,
+			DedupKey:      dedupKey,
+			Action:        action,
+			Status:        model.OutboxSending,
+			NextAttemptAt: time.Now().Add(sendLease),
+			AlertID:       t.AlertID,
+			DomainID:      t.DomainID,
+			ServerID:      t.ServerID,
+			Ip:            t.Ip,
+		}
+		if err := db.Create(msg).Error; err != nil {
+			errs = append(errs, fmt.Errorf("%s: 保存消息失败: %w", channel, err))
+			continue
+		}
+		if err := attempt(db, msg, ""); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProcessDue 发送所有到期的待发送消息，以及领取已过期（发送中进程退出）的消息。
+// 每条消息先以条件更新领取，领取失败说明已被其他进程或请求领取，跳过
+func ProcessDue(db *gorm.DB) {
+	now := time.Now()
+	var messages []model.OutboxMessage
+	err := db.Where("status IN ? AND next_attempt_at <= ?", []string{model.OutboxPending, model.OutboxSending}, now).
+		Order("next_attempt_at ASC").Limit(100).Find(&messages).Error
+	if err != nil {
+		log.Printf("Outbox: failed to load pending messages: %v", err)
+		return
+	}
+	for i := range messages {
+		msg := &messages[i]
+		claimed, err := claim(db, msg, now)
+		if err != nil {
+			log.Printf("Outbox: failed to claim message %d: %v", msg.ID, err)
+			continue
+		}
+		if claimed {
+			attempt(db, msg, "")
+		}
+	}
+}
+
+// claim 将到期的消息标记为发送中，返回是否领取成功
+func claim(db *gorm.DB, msg *model.OutboxMessage, now time.Time) (bool, error) {
+	lease := now.Add(sendLease)
+	result := db.Model(&model.OutboxMessage{}).
+		Where("id = ? AND status IN ? AND next_attempt_at <= ?", msg.ID, []string{model.OutboxPending, model.OutboxSending}, now).
+		Updates(map[string]interface{}{"status": model.OutboxSending, "next_attempt_at": lease})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	msg.Status = model.OutboxSending
+	msg.NextAttemptAt = lease
+	return true, nil
+}
+
+// Resend 手动重新发送消息（包括已进入死信状态的消息），重置重试计数，user 记录在发送记录中。
+// 消息正在发送中时返回 ErrInFlight
+func Resend(db *gorm.DB, msg *model.OutboxMessage, user string) error {
+	now := time.Now()
+	lease := now.Add(sendLease)
+	result := db.Model(&model.OutboxMessage{}).
+		Where("id = ? AND (status <> ? OR next_attempt_at <= ?)", msg.ID, model.OutboxSending, now).
+		Updates(map[string]interface{}{"status": model.OutboxSending, "attempts": 0, "next_attempt_at": lease})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInFlight
+	}
+	msg.Status = model.OutboxSending
+	msg.Attempts = 0
+	msg.NextAttemptAt = lease
+	return attempt(db, msg, user)
+}
+
+// SendTest 不经过发件箱直接向渠道发送测试通知，同样记录发送结果，返回渠道的应答
+func SendTest(db *gorm.DB, n notify.Notifier, notification notify.Notification, user string) (string, error) {
+	record := model.NotificationAttempt{
+		Kind:        notification.Kind,
+		Action:      notification.Action,
+		Channel:     n.Name(),
+		ChannelType: n.Type(),
+		Recipients:  recipients(n.Name(), notification.To),
+		Subject:     notification.Subject,
+		Attempt:     1,
+		User:        user,
+		StartedAt:   time.Now(),
+	}
+	response, err := n.Send(notification)
+	finish(db, &record, response, err)
+	return response, err
+}
+
+// attempt 发送一次消息并更新状态，每次发送写入一条发送记录
+func attempt(db *gorm.DB, msg *model.OutboxMessage, user string) error {
+	record := model.NotificationAttempt{
+		OutboxMessageID: msg.ID,
+		Kind:            msg.Kind,
+		Action:          msg.Action,
+		Channel:         msg.Channel,
+		Recipients:      msg.Recipients,
+		Subject:         msg.Subject,
+		Attempt:         msg.Attempts + 1,
+		AlertID:         msg.AlertID,
+		DomainID:        msg.DomainID,
+		ServerID:        msg.ServerID,
+		Ip:              msg.Ip,
+		BackupLogID:     msg.BackupLogID,
+		User:            user,
+		StartedAt:       time.Now(),
+	}
+	if n, ok := notify.Get(msg.Channel); ok {
+		record.ChannelType = n.Type()
+		record.Recipients = recipients(msg.Channel, splitRecipients(msg.Recipients))
+	}
+	response, err := send(msg)
+	finish(db, &record, response, err)
+	msg.Attempts++
+
+	if err == nil {
+		msg.Status = model.OutboxSent
+		msg.SentAt = time.Now()
+		msg.LastError = ""
+		if msg.BackupLogID != 0 {
+			// 告警最终发送成功，更新备份日志的告警状态
+			db.Model(&model.BackupLog{}).
+				Where("id = ? AND alert_status = ?", msg.BackupLogID, model.AlertStatusFailed).
+				Update("alert_status", msg.SentAlertStatus)
+		}
+	} else if errors.Is(err, email.ErrDisabled) {
+		msg.Status = model.OutboxSkipped
+		msg.LastError = err.Error()
+	} else {
+		msg.LastError = err.Error()
+		if msg.Attempts >= maxAttempts() {
+			msg.Status = model.OutboxDead
+			log.Printf("Outbox: message %d moved to dead letter after %d attempts: %v", msg.ID, msg.Attempts, err)
+		} else {
+			msg.Status = model.OutboxPending
+			msg.NextAttemptAt = time.Now().Add(Backoff(msg.Attempts))
+		}
+	}
+
+	if msg.ID != 0 {
+		if saveErr := db.Save(msg).Error; saveErr != nil {
+			log.Printf("Outbox: failed to update message %d: %v", msg.ID, saveErr)
+		}
+	}
+	return err
+}
+
+func send(msg *model.OutboxMessage) (string, error) {
+	n, ok := notify.Get(msg.Channel)
+	if !ok {
+		return "", fmt.Errorf("未定义的通知渠道: %s", msg.Channel)
+	}
+	var attachments []email.Attachment
+	if msg.Attachments != "" {
+		if err := json.Unmarshal([]byte(msg.Attachments), &attachments); err != nil {
+			return "", fmt.Errorf("附件解码失败: %w", err)
+		}
+	}
+	return n.Send(notify.Notification{
+		Kind:        msg.Kind,
+		To:          splitRecipients(msg.Recipients),
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		HTML:        msg.HTMLBody,
+		Severity:    msg.Severity,
+		DedupKey:    msg.DedupKey,
+		Action:      msg.Action,
+		Attachments: attachments,
+	})
+}
+
+// finish 写入发送结果和耗时并保存发送记录
+func finish(db *gorm.DB, record *model.NotificationAttempt, response string, err error) {
+	record.DurationMs = time.Since(record.StartedAt).Milliseconds()
+	record.Response = truncate(response, 4000)
+	switch {
+	case err == nil:
+		record.Status = model.AttemptSent
+	case errors.Is(err, email.ErrDisabled):
+		record.Status = model.AttemptSkipped
+		record.Error = err.Error()
+	default:
+		record.Status = model.AttemptFailed
+		record.Error = err.Error()
+	}
+	if saveErr := db.Create(record).Error; saveErr != nil {
+		log.Printf("Outbox: failed to record %s attempt on %s: %v", record.Kind, record.Channel, saveErr)
+	}
+}
+
+// recipients 返回实际的邮件收件人：未指定时为默认收件人，非邮件渠道为空
+func recipients(channel string, to []string) string {
+	n, ok := notify.Get(channel)
+	if !ok || n.Type() != "email" {
+		return ""
+	}
+	if len(to) == 0 {
+		to = notify.DefaultRecipients()
+	}
+	return strings.Join(to, ",")
+}
+
+func splitRecipients(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+// Backoff 返回第 n 次失败后的重试间隔：1分钟、2分钟、4分钟……最长 6 小时
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+func maxAttempts() int {
+	if n := config.AppConfig.Outbox.MaxAttempts; n > 0 {
+		return n
+	}
+	return 8
+}
@@ -8,6 +8,11 @@ serverapp="/KiosoftApplications/ServerApps"
 logs_dir="/back/rsync/logs"
 code_dir="/back/rsync/code"
 apiServerAdd="http://20.83.180.26:8080"
+apiToken=""  # 摄取令牌（bsa_...），为空时不发送认证头，需在服务端 backup.legacy_allowed_ips 中放行本机地址
+auth_header=()
+if [ -n "$apiToken" ]; then
+    auth_header=(-H "Authorization: Bearer $apiToken")
+fi
 
 
 
@@ -146,7 +151,7 @@ rabbitmq_compress_and_move_logs() {
 #rm -rf $logs_dir/universal_ddd/*
 #rm -rf $logs_dir/rabbitmq/*
 #================================================================
-response=$(curl -s "$apiServerAdd/getStatus/$public_ip")
+response=$(curl -s "${auth_header[@]}" "$apiServerAdd/getStatus/$public_ip")
 backup_status=$(echo "$response" | grep -oP '"backup_status":\K\d+')
 echo $response
 if [ -n "$backup_status" ]; then
@@ -162,7 +167,7 @@ fi
 #============================================================================
 #记录执行时间
 #============================================================================
-curl -X POST $apiServerAdd/backupLogs \
+curl -X POST "${auth_header[@]}" $apiServerAdd/backupLogs \
 -H "Content-Type: application/json" \
 -d "{
     \"ip\": \"$public_ip\",
@@ -172,7 +177,7 @@ curl -X POST $apiServerAdd/backupLogs \
     \"alert_status\": 1
 }"
 
-response=$(curl -s "$apiServerAdd/getId/$public_ip")
+response=$(curl -s "${auth_header[@]}" "$apiServerAdd/getId/$public_ip")
 id=$(echo "$response" | grep -oP '"id":\K\d+')
 if [ -n "$id" ]; then
     log "获取到的 ID: $id"
@@ -220,7 +225,7 @@ cpulimit -l $percentage /usr/bin/rsync -avz --password-file=/etc/rsync.password
 if [ $? -eq 0 ]; then
     log "rsync 成功"
 
-    curl -X PUT "$apiServerAdd/backupLogs/$id" \
+    curl -X PUT "${auth_header[@]}" "$apiServerAdd/backupLogs/$id" \
 -H "Content-Type: application/json" \
 -d "{
     \"end_time\": \"$(date '+%Y-%m-%d %H:%M:%S')\",
@@ -232,7 +237,7 @@ if [ $? -eq 0 ]; then
 else
     echo "rsync 失败"
 
-    curl -X PUT "$apiServerAdd/backupLogs/$id" \
+    curl -X PUT "${auth_header[@]}" "$apiServerAdd/backupLogs/$id" \
 -H "Content-Type: application/json" \
 -d "{
     \"end_time\": \"$(date '+%Y-%m-%d %H:%M:%S')\",
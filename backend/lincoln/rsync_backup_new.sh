@@ -6,6 +6,11 @@
 script_version="1.0.0.1"
 
 apiServerAdd="http://20.83.180.26:8080"
+apiToken=""  # 摄取令牌（bsa_...），为空时不发送认证头，需在服务端 backup.legacy_allowed_ips 中放行本机地址
+auth_header=()
+if [ -n "$apiToken" ]; then
+    auth_header=(-H "Authorization: Bearer $apiToken")
+fi
 
 rsync_name=$(/usr/bin/hostname)
 rsync_user=$(/usr/bin/hostname | awk -F '-' '{print$2}')
@@ -95,7 +100,7 @@ function compress_and_move_logs() {
 #判断上一次备份是否成功，备份成功则删除昨天的日志不成功则不删除
 #
 #================================================================
-response=$(curl -s "$apiServerAdd/getStatus/$public_ip")
+response=$(curl -s "${auth_header[@]}" "$apiServerAdd/getStatus/$public_ip")
 backup_status=$(echo "$response" | grep -oP '"backup_status":\K\d+')
 echo $response
 if [ -n "$backup_status" ]; then
@@ -110,7 +115,7 @@ fi
 #============================================================================
 #记录开始时间
 #============================================================================
-curl -X POST $apiServerAdd/backupLogs \
+curl -X POST "${auth_header[@]}" $apiServerAdd/backupLogs \
     -H "Content-Type: application/json" \
     -d "{
     \"ip\": \"$public_ip\",
@@ -124,7 +129,7 @@ if [ $? -ne 0 ]; then
     log "Failed to post backup start log"
     exit 1
 fi
-response=$(curl -s "$apiServerAdd/getId/$public_ip")
+response=$(curl -s "${auth_header[@]}" "$apiServerAdd/getId/$public_ip")
 id=$(echo "$response" | grep -oP '"id":\K\d+')
 if [ -n "$id" ]; then
     log "获取到的 ID: $id"
@@ -232,7 +237,7 @@ cpulimit -l $percentage /usr/bin/rsync -avz --password-file=/etc/rsync.password
 if [ $? -eq 0 ]; then
     log "rsync 成功"
 
-    curl -X PUT "$apiServerAdd/backupLogs/$id" \
+    curl -X PUT "${auth_header[@]}" "$apiServerAdd/backupLogs/$id" \
         -H "Content-Type: application/json" \
         -d "{
     \"end_time\": \"$(date '+%Y-%m-%d %H:%M:%S')\",
@@ -243,7 +248,7 @@ if [ $? -eq 0 ]; then
 else
     echo "rsync 失败"
 
-    curl -X PUT "$apiServerAdd/backupLogs/$id" \
+    curl -X PUT "${auth_header[@]}" "$apiServerAdd/backupLogs/$id" \
         -H "Content-Type: application/json" \
         -d "{
     \"end_time\": \"$(date '+%Y-%m-%d %H:%M:%S')\",
@@ -15,6 +15,9 @@ type CertInfo struct {
 	RemainingDays    int       `json:"remaining_days"`
 	IsValid          bool      `json:"is_valid"`
 	ValidationErrors []string  `json:"validation_errors,omitempty"`
+	SANs             []string  `json:"sans,omitempty"`
+	Fingerprint      string    `json:"fingerprint,omitempty"` // 叶子证书 SHA-256 指纹
+	Chain            []ChainCert `json:"chain,omitempty"`
 }
 
 func CheckCertificate(domain string) (*CertInfo, error) {
@@ -36,7 +39,8 @@ func CheckCertificate(domain string) (*CertInfo, error) {
 	defer conn.Close()
 
 	// 获取证书信息
-	cert := conn.ConnectionState().PeerCertificates[0]
+	peerCerts := conn.ConnectionState().PeerCertificates
+	cert := peerCerts[0]
 	now := time.Now()
 
 	info := &CertInfo{
@@ -46,6 +50,9 @@ func CheckCertificate(domain string) (*CertInfo, error) {
 		NotAfter:      cert.NotAfter,
 		RemainingDays: int(cert.NotAfter.Sub(now).Hours() / 24),
 		IsValid:       true,
+		SANs:          CertSANs(cert),
+		Fingerprint:   Fingerprint(cert),
+		Chain:         DescribeChain(peerCerts),
 	}
 
 	// 验证证书
@@ -0,0 +1,237 @@
+package ssl
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// TLSA 证书用途 (RFC 6698)
+const (
+	TLSAUsagePKIXTA = 0
+	TLSAUsagePKIXEE = 1
+	TLSAUsageDANETA = 2
+	TLSAUsageDANEEE = 3
+)
+
+// DefaultDNSSECResolver 未配置解析器时使用的 DNSSEC 验证解析器
+const DefaultDNSSECResolver = "1.1.1.1:53"
+
+type TLSARecord struct {
+	Usage        uint8  `json:"usage"`
+	Selector     uint8  `json:"selector"`
+	MatchingType uint8  `json:"matching_type"`
+	Data         string `json:"data"`
+	Matched      bool   `json:"matched"`
+}
+
+type DANEResult struct {
+	Name         string       `json:"name"`
+	Port         int          `json:"port"`
+	Secure       bool         `json:"secure"`
+	Records      []TLSARecord `json:"records"`
+	Valid        bool         `json:"valid"`
+	Mismatch     bool         `json:"mismatch"`
+	StaleRecords []TLSARecord `json:"stale_records,omitempty"`
+	Errors       []string     `json:"errors,omitempty"`
+}
+
+// TLSAName 返回 _port._proto.host 形式的 TLSA 记录名
+func TLSAName(host string, port int, proto string) string {
+	if proto == "" {
+		proto = "tcp"
+	}
+	return fmt.Sprintf("_%d._%s.%s", port, proto, dns.Fqdn(host))
+}
+
+// LookupTLSA 通过支持 DNSSEC 的解析器查询 TLSA 记录，返回记录及应答是否经过 DNSSEC 验证
+func LookupTLSA(resolver, name string) ([]TLSARecord, bool, error) {
+	if resolver == "" {
+		resolver = DefaultDNSSECResolver
+	}
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		resolver = net.JoinHostPort(resolver, "53")
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeTLSA)
+	msg.SetEdns0(4096, true)
+	msg.AuthenticatedData = true
+
+	client := &dns.Client{Net: "tcp", Timeout: 5 * time.Second}
+	resp, _, err := client.Exchange(msg, resolver)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, false, fmt.Errorf("DNS查询失败: %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	var records []TLSARecord
+	for _, rr := range resp.Answer {
+		if t, ok := rr.(*dns.TLSA); ok {
+			records = append(records, TLSARecord{
+				Usage:        t.Usage,
+				Selector:     t.Selector,
+				MatchingType: t.MatchingType,
+				Data:         strings.ToLower(t.Certificate),
+			})
+		}
+	}
+	return records, resp.AuthenticatedData, nil
+}
+
+// FetchChain 连接到指定端点并返回对端证书链，SMTP 端口使用 STARTTLS
+func FetchChain(host string, port int) ([]*x509.Certificate, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: true}
+
+	switch port {
+	case 25, 587:
+		conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		client, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		defer client.Close()
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return nil, err
+		}
+		state, ok := client.TLSConnectionState()
+		if !ok {
+			return nil, fmt.Errorf("STARTTLS 未建立")
+		}
+		return state.PeerCertificates, nil
+	default:
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates, nil
+	}
+}
+
+// CheckDANE 查询 TLSA 记录并与端点实际提供的证书链进行比对
+func CheckDANE(resolver, host string, port int) *DANEResult {
+	result := &DANEResult{Name: TLSAName(host, port, "tcp"), Port: port}
+
+	records, secure, err := LookupTLSA(resolver, result.Name)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("TLSA查询失败: %v", err))
+		return result
+	}
+	result.Secure = secure
+	if len(records) == 0 {
+		result.Errors = append(result.Errors, "未找到TLSA记录")
+		return result
+	}
+	if !secure {
+		result.Errors = append(result.Errors, "TLSA应答未通过DNSSEC验证")
+	}
+
+	chain, err := FetchChain(host, port)
+	if err != nil {
+		result.Records = records
+		result.Errors = append(result.Errors, fmt.Sprintf("连接失败: %v", err))
+		return result
+	}
+
+	result.Records = VerifyTLSA(records, chain, host)
+	for _, r := range result.Records {
+		if r.Matched {
+			result.Valid = true
+		} else {
+			result.StaleRecords = append(result.StaleRecords, r)
+		}
+	}
+	result.Mismatch = !result.Valid
+	return result
+}
+
+// VerifyTLSA 按用途/选择器/匹配类型逐条比对 TLSA 记录，返回带匹配结果的记录
+func VerifyTLSA(records []TLSARecord, chain []*x509.Certificate, host string) []TLSARecord {
+	out := make([]TLSARecord, len(records))
+	if len(chain) == 0 {
+		copy(out, records)
+		return out
+	}
+
+	pkixValid := verifyPKIX(chain, host)
+	for i, r := range records {
+		out[i] = r
+		var candidates []*x509.Certificate
+		switch r.Usage {
+		case TLSAUsagePKIXEE, TLSAUsageDANEEE:
+			candidates = chain[:1]
+		case TLSAUsagePKIXTA, TLSAUsageDANETA:
+			candidates = chain[1:]
+		default:
+			continue
+		}
+		if (r.Usage == TLSAUsagePKIXTA || r.Usage == TLSAUsagePKIXEE) && !pkixValid {
+			continue
+		}
+		for _, cert := range candidates {
+			if matchTLSA(r, cert) {
+				out[i].Matched = true
+				break
+			}
+		}
+	}
+	return out
+}
+
+func matchTLSA(r TLSARecord, cert *x509.Certificate) bool {
+	var data []byte
+	switch r.Selector {
+	case 0:
+		data = cert.Raw
+	case 1:
+		data = cert.RawSubjectPublicKeyInfo
+	default:
+		return false
+	}
+
+	var digest []byte
+	switch r.MatchingType {
+	case 0:
+		digest = data
+	case 1:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case 2:
+		sum := sha512.Sum512(data)
+		digest = sum[:]
+	default:
+		return false
+	}
+	return hex.EncodeToString(digest) == strings.ToLower(r.Data)
+}
+
+func verifyPKIX(chain []*x509.Certificate, host string) bool {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+	return err == nil
+}
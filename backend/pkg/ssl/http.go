@@ -0,0 +1,120 @@
+package ssl
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 需要记录的安全相关响应头
+var securityHeaders = []string{
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Referrer-Policy",
+}
+
+type HSTSInfo struct {
+	Present           bool  `json:"present"`
+	MaxAge            int64 `json:"max_age"`
+	IncludeSubDomains bool  `json:"include_sub_domains"`
+	Preload           bool  `json:"preload"`
+}
+
+type HTTPCheckResult struct {
+	URL              string            `json:"url"`
+	StatusCode       int               `json:"status_code"`
+	HSTS             HSTSInfo          `json:"hsts"`
+	RedirectsToHTTPS bool              `json:"redirects_to_https"`
+	RedirectStatus   int               `json:"redirect_status"`
+	RedirectLocation string            `json:"redirect_location,omitempty"`
+	Headers          map[string]string `json:"headers"`
+	Errors           []string          `json:"errors,omitempty"`
+}
+
+// CheckHTTP 在 TLS 检查之后请求站点页面，记录 HSTS、HTTP→HTTPS 跳转及安全响应头
+func CheckHTTP(host, path string) *HTTPCheckResult {
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+		// 不自动跟随跳转，以便记录跳转行为
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	result := &HTTPCheckResult{
+		URL:     "https://" + host + path,
+		Headers: make(map[string]string),
+	}
+
+	resp, err := client.Get(result.URL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("HTTPS请求失败: %v", err))
+	} else {
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+		result.HSTS = ParseHSTS(resp.Header.Get("Strict-Transport-Security"))
+		for _, h := range securityHeaders {
+			if v := resp.Header.Get(h); v != "" {
+				result.Headers[h] = v
+			}
+		}
+	}
+
+	// 监控地址中的端口是 HTTPS 端口，明文请求使用默认 80 端口
+	httpHost := Hostname(host)
+	if strings.Contains(httpHost, ":") {
+		httpHost = "[" + httpHost + "]"
+	}
+	resp, err = client.Get("http://" + httpHost + path)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("HTTP请求失败: %v", err))
+		return result
+	}
+	resp.Body.Close()
+	result.RedirectStatus = resp.StatusCode
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		result.RedirectLocation = resp.Header.Get("Location")
+		if loc, err := url.Parse(result.RedirectLocation); err == nil && loc.Scheme == "https" {
+			result.RedirectsToHTTPS = true
+		}
+	}
+
+	return result
+}
+
+// ParseHSTS 解析 Strict-Transport-Security 响应头
+func ParseHSTS(value string) HSTSInfo {
+	info := HSTSInfo{}
+	if strings.TrimSpace(value) == "" {
+		return info
+	}
+	info.Present = true
+	for _, directive := range strings.Split(value, ";") {
+		directive = strings.TrimSpace(directive)
+		name, val, _ := strings.Cut(directive, "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			info.MaxAge, _ = strconv.ParseInt(strings.Trim(strings.TrimSpace(val), `"`), 10, 64)
+		case "includesubdomains":
+			info.IncludeSubDomains = true
+		case "preload":
+			info.Preload = true
+		}
+	}
+	return info
+}
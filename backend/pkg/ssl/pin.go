@@ -0,0 +1,59 @@
+package ssl
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ChainCert 证书链中单张证书的摘要信息
+type ChainCert struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+	SPKIPin string `json:"spki_pin"` // SHA-256(SubjectPublicKeyInfo) 的 base64 编码
+}
+
+// SPKIPin 计算证书公钥的 SHA-256 pin (base64 编码，与 HPKP pin-sha256 格式一致)
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Fingerprint 计算证书 DER 的 SHA-256 指纹
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// DescribeChain 返回证书链中每张证书的主题、签发者和 SPKI pin
+func DescribeChain(chain []*x509.Certificate) []ChainCert {
+	out := make([]ChainCert, 0, len(chain))
+	for _, cert := range chain {
+		out = append(out, ChainCert{
+			Subject: cert.Subject.CommonName,
+			Issuer:  cert.Issuer.CommonName,
+			SPKIPin: SPKIPin(cert),
+		})
+	}
+	return out
+}
+
+// NormalizePin 规范化 pin 输入，支持 "sha256/<base64>"、base64 及十六进制格式
+func NormalizePin(pin string) (string, error) {
+	pin = strings.TrimSpace(pin)
+	pin = strings.TrimPrefix(pin, "sha256/")
+	pin = strings.TrimPrefix(pin, "pin-sha256=")
+	pin = strings.Trim(pin, `"`)
+
+	if raw, err := hex.DecodeString(pin); err == nil && len(raw) == sha256.Size {
+		return base64.StdEncoding.EncodeToString(raw), nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(raw) != sha256.Size {
+		return "", fmt.Errorf("无效的SHA-256 pin: %s", pin)
+	}
+	return pin, nil
+}
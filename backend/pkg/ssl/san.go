@@ -0,0 +1,57 @@
+package ssl
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+)
+
+// CertSANs 返回证书的全部 SAN：DNS 名称及 IP 地址（文本形式）
+func CertSANs(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// MatchHostname 判断主机名是否被某个 SAN 覆盖，通配符仅匹配最左侧的一级标签；IP 地址只与相同的 IP SAN 匹配
+func MatchHostname(host, san string) bool {
+	if hostIP := net.ParseIP(host); hostIP != nil {
+		return hostIP.Equal(net.ParseIP(san))
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	san = strings.ToLower(strings.TrimSuffix(san, "."))
+	if host == san {
+		return true
+	}
+	if !strings.HasPrefix(san, "*.") {
+		return false
+	}
+	i := strings.Index(host, ".")
+	if i <= 0 {
+		return false
+	}
+	return host[i+1:] == san[2:]
+}
+
+// CoveredBy 判断监控地址是否被任一 SAN 覆盖，地址可带端口（如 example.com:8443、[2001:db8::1]:443），
+// SAN 不含端口，只比较主机部分
+func CoveredBy(domain string, sans []string) bool {
+	host := Hostname(domain)
+	for _, san := range sans {
+		if MatchHostname(host, san) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hostname 去掉监控地址中的端口，返回主机名或 IP
+func Hostname(domain string) string {
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(domain, "["), "]")
+}
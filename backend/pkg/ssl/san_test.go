@@ -0,0 +1,47 @@
+package ssl
+
+import (
+	"crypto/x509"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestCoveredBy(t *testing.T) {
+	sans := []string{"example.com", "*.example.com", "2001:db8::1"}
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"example.com", true},
+		{"example.com:443", true},
+		{"www.example.com:8443", true},
+		{"Example.COM.", true},
+		{"a.b.example.com", false},
+		{"a.b.example.com:443", false},
+		{"example.org:443", false},
+		{"[2001:db8::1]:443", true},
+		{"2001:db8::1", true},
+		{"[2001:db8::2]:443", false},
+		{"[2001:0db8:0::1]:443", true},
+	}
+	for _, tt := range tests {
+		if got := CoveredBy(tt.domain, sans); got != tt.want {
+			t.Errorf("CoveredBy(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestCertSANsIncludesIPAddresses(t *testing.T) {
+	cert := &x509.Certificate{
+		DNSNames:    []string{"example.com"},
+		IPAddresses: []net.IP{net.ParseIP("192.0.2.10"), net.ParseIP("2001:db8::1")},
+	}
+	want := []string{"example.com", "192.0.2.10", "2001:db8::1"}
+	if got := CertSANs(cert); !reflect.DeepEqual(got, want) {
+		t.Errorf("CertSANs = %v, want %v", got, want)
+	}
+	if !CoveredBy("192.0.2.10:8443", CertSANs(cert)) {
+		t.Error("IP address SAN does not cover the monitored IP")
+	}
+}
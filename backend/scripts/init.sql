@@ -48,16 +48,21 @@ CREATE TABLE IF NOT EXISTS domains (
 --     ('example.com', 'admin@example.com', 'VALID', true),
 --     ('test.com', 'admin@test.com', 'VALID', true); 
 
--- 添加备份日志表
-CREATE TABLE backup_logs (
+-- 添加备份日志表（服务启动时会自动迁移，并导入原 BackupLogs 表的数据）
+CREATE TABLE IF NOT EXISTS backup_logs (
     id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
-    ip VARCHAR(15) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    ip_bin VARBINARY(16) NULL,
     server_name VARCHAR(100) NOT NULL,
-    start_time VARCHAR(255) NOT NULL DEFAULT '',
-    end_time VARCHAR(255) NOT NULL DEFAULT '',
+    start_time DATETIME(3) NULL,
+    end_time DATETIME(3) NULL,
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
     backup_status TINYINT NOT NULL DEFAULT 0,
-    alert_status TINYINT NOT NULL DEFAULT 0,
+    alert_status TINYINT NOT NULL DEFAULT 0 COMMENT '0:正常,1:告警已触发,2:告警未触发',
     script_version VARCHAR(255) NOT NULL DEFAULT '0',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4; 
\ No newline at end of file
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_backup_logs_ip (ip),
+    INDEX idx_backup_logs_ip_bin (ip_bin),
+    INDEX idx_backup_logs_start_time (start_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;